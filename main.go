@@ -1,83 +1,195 @@
 package main
 
 import (
+	"bytes"
+	"compress/gzip"
+	"container/list"
+	"context"
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"database/sql"
+	_ "embed"
+	"encoding/base64"
+	"encoding/csv"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
-	"log"
+	"hash"
+	"io"
+	"log/slog"
+	"maps"
+	"math"
+	mathrand "math/rand"
+	"net"
 	"net/http"
+	"net/url"
 	"os"
+	"os/signal"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
+	"syscall"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/rivo/uniseg"
+	"golang.org/x/text/unicode/norm"
+	"golang.org/x/time/rate"
+	_ "modernc.org/sqlite"
 )
 
 func main() {
-	// Get port from environment or use default
-	port := os.Getenv("PORT")
+	startTime := time.Now()
+	setupLogger()
+
+	portFlag := flag.String("port", "", "port to listen on (overrides PORT)")
+	host := flag.String("host", "0.0.0.0", "host interface to listen on")
+	flag.Parse()
+
+	// Get port from the -port flag, then the environment, then the default
+	port := *portFlag
+	if port == "" {
+		port = os.Getenv("PORT")
+	}
 	if port == "" {
 		port = "8080"
 	}
 
-	// Initialize storage
-	store := NewMemoryStore()
+	config := LoadConfig()
+
+	// Initialize storage, persisting to SQLite when SQLITE_PATH is set or to
+	// a JSON file when STORE_FILE is set
+	var store Store = NewMemoryStore(config.MaxEntries)
+	if path := os.Getenv("SQLITE_PATH"); path != "" {
+		sqliteStore, err := NewSQLiteStore(path)
+		if err != nil {
+			slog.Error("failed to open SQLite database", "path", path, "error", err)
+			os.Exit(1)
+		}
+		slog.Info("persisting strings to SQLite database", "path", path)
+		if config.MaxEntries > 0 {
+			slog.Warn("MAX_ENTRIES only applies to the memory and file stores, ignoring it", "max_entries", config.MaxEntries)
+		}
+		store = sqliteStore
+	} else if path := os.Getenv("STORE_FILE"); path != "" {
+		fileStore, err := NewFileStore(path, config.MaxEntries)
+		if err != nil {
+			slog.Error("failed to open store file", "path", path, "error", err)
+			os.Exit(1)
+		}
+		slog.Info("persisting strings to file", "path", path)
+		store = fileStore
+	}
 
 	// Initialize handlers
-	handler := NewStringHandler(store)
+	metrics := NewMetrics()
+	limiter := NewIPRateLimiter(rate.Limit(config.RateLimitRPS), config.RateLimitBurst)
+	handler := NewStringHandler(store, metrics, config)
 
 	// Setup routes
 	mux := http.NewServeMux()
 
-	// Router wrapper to handle path-based routing
-	mux.HandleFunc("/strings", func(w http.ResponseWriter, r *http.Request) {
-		// Enable CORS
-		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, DELETE, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
-
-		if r.Method == http.MethodOptions {
-			w.WriteHeader(http.StatusOK)
-			return
-		}
-
-		path := r.URL.Path
+	// Each route declares a handler per allowed method. Routes are tried in
+	// order, so the fixed paths and sub-routes come before the catch-all
+	// /strings/{value}; a matching route without a handler for the method
+	// falls through, so a value named "stats" can still be deleted. Only
+	// when no matching route accepts the method is it answered with 405.
+	stringRoutes := []struct {
+		match    func(path string) bool
+		handlers methodHandlers
+	}{
+		{pathPrefix("/strings/filter-by-natural-language"), methodHandlers{
+			http.MethodGet:  handler.FilterByNaturalLanguage,
+			http.MethodPost: handler.FilterByNaturalLanguage,
+		}},
+		{pathEquals("/strings/bulk"), methodHandlers{http.MethodPost: handler.BulkCreateString}},
+		{pathEquals("/strings/import"), methodHandlers{http.MethodPost: handler.ImportStrings}},
+		{pathEquals("/strings/batch-get"), methodHandlers{http.MethodPost: handler.BatchGetStrings}},
+		{pathEquals("/strings/purge"), methodHandlers{http.MethodPost: handler.PurgeDeletedStrings}},
+		{pathEquals("/strings/analyze"), methodHandlers{
+			http.MethodGet:  handler.AnalyzeString,
+			http.MethodPost: handler.AnalyzeString,
+		}},
+		{pathEquals("/strings/export"), methodHandlers{http.MethodGet: handler.ExportStrings}},
+		{pathEquals("/strings/anagrams"), methodHandlers{http.MethodGet: handler.GetAnagramGroups}},
+		{pathEquals("/strings/duplicates"), methodHandlers{http.MethodGet: handler.GetDuplicateIDs}},
+		{pathEquals("/strings/stats"), methodHandlers{http.MethodGet: handler.GetStats}},
+		{pathEquals("/strings/charset"), methodHandlers{http.MethodGet: handler.GetCharset}},
+		{pathEquals("/strings/diff"), methodHandlers{http.MethodGet: handler.DiffStrings}},
+		{pathEquals("/strings/lcs"), methodHandlers{http.MethodGet: handler.LongestCommonSubstring}},
+		{pathEquals("/strings/random"), methodHandlers{http.MethodGet: handler.GetRandomStrings}},
+		{pathEquals("/strings/search"), methodHandlers{http.MethodGet: handler.SearchStrings}},
+		{pathPrefix("/strings/by-id/"), methodHandlers{http.MethodGet: handler.GetStringByID}},
+		{pathSuffix("/similar"), methodHandlers{http.MethodGet: handler.GetSimilarStrings}},
+		{pathSuffix("/frequency"), methodHandlers{http.MethodGet: handler.GetCharacterFrequency}},
+		{pathSuffix("/palindromes"), methodHandlers{http.MethodGet: handler.GetPalindromicSubstrings}},
+		{pathSuffix("/exists"), methodHandlers{
+			http.MethodGet:  handler.StringExists,
+			http.MethodHead: handler.StringExists,
+		}},
+		{pathEquals("/strings", "/strings/"), methodHandlers{
+			http.MethodPost:   handler.CreateString,
+			http.MethodGet:    handler.GetAllStrings,
+			http.MethodDelete: handler.DeleteAllStrings,
+		}},
+		{pathPrefix("/strings/"), methodHandlers{
+			http.MethodGet:    handler.GetString,
+			http.MethodHead:   handler.StringExists,
+			http.MethodPut:    handler.UpdateString,
+			http.MethodDelete: handler.DeleteString,
+		}},
+	}
 
-		// Route: GET /strings/filter-by-natural-language
-		if strings.HasPrefix(path, "/strings/filter-by-natural-language") {
-			handler.FilterByNaturalLanguage(w, r)
-			return
-		}
+	stringsRouter := func(w http.ResponseWriter, r *http.Request) {
+		// Route on the escaped path so an encoded "/" inside a value
+		// (e.g. "a%2Fsimilar") can't be mistaken for a sub-route
+		path := r.URL.EscapedPath()
 
-		// Route: GET /strings/{value} or DELETE /strings/{value}
-		if path != "/strings" && path != "/strings/" {
-			if r.Method == http.MethodGet {
-				handler.GetString(w, r)
-			} else if r.Method == http.MethodDelete {
-				handler.DeleteString(w, r)
-			} else {
-				http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		allowed := methodHandlers{}
+		for _, route := range stringRoutes {
+			if !route.match(path) {
+				continue
 			}
-			return
+			if handler, ok := route.handlers[r.Method]; ok {
+				handler(w, r)
+				return
+			}
+			maps.Copy(allowed, route.handlers)
 		}
+		allowed.ServeHTTP(w, r)
+	}
+	mux.HandleFunc("/strings", stringsRouter)
+	mux.HandleFunc("/strings/", stringsRouter)
 
-		// Route: POST /strings or GET /strings (with filters)
-		if r.Method == http.MethodPost {
-			handler.CreateString(w, r)
-		} else if r.Method == http.MethodGet {
-			handler.GetAllStrings(w, r)
-		} else {
-			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-		}
-	})
+	// Prometheus metrics endpoint
+	mux.Handle("/metrics", methodHandlers{http.MethodGet: metrics.Handler(store)})
 
-	// Handle the filter-by-natural-language endpoint specifically
-	mux.HandleFunc("/strings/filter-by-natural-language", func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Access-Control-Allow-Origin", "*")
-		handler.FilterByNaturalLanguage(w, r)
-	})
+	// OpenAPI description of the API
+	mux.Handle("/openapi.json", methodHandlers{http.MethodGet: serveOpenAPI})
 
 	// Health check endpoint
-	mux.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
-		w.WriteHeader(http.StatusOK)
-		w.Write([]byte("OK"))
-	})
+	health := func(w http.ResponseWriter, r *http.Request) {
+		respondJSON(w, http.StatusOK, map[string]interface{}{
+			"status":         "ok",
+			"uptime_seconds": int64(time.Since(startTime).Seconds()),
+			"stored_count":   store.Len(),
+		})
+	}
+	mux.Handle("/health", methodHandlers{http.MethodGet: health, http.MethodHead: health})
+
+	// Readiness check that exercises the store, unlike /health
+	mux.Handle("/ready", methodHandlers{http.MethodGet: handler.Ready})
 
 	// Root endpoint
 	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
@@ -86,388 +198,4686 @@ func main() {
 			w.WriteHeader(http.StatusOK)
 			w.Write([]byte(`{"message": "String Analyzer API", "version": "1.0.0"}`))
 		} else {
-			http.NotFound(w, r)
+			respondNotFound(w, r)
 		}
 	})
 
 	// Start server
-	addr := "0.0.0.0:" + port
-	log.Printf("Server starting on %s", addr)
-	log.Printf("Available endpoints:")
-	log.Printf("  POST   /strings")
-	log.Printf("  GET    /strings")
-	log.Printf("  GET    /strings/{value}")
-	log.Printf("  GET    /strings/filter-by-natural-language")
-	log.Printf("  DELETE /strings/{value}")
+	addr := net.JoinHostPort(*host, port)
+	slog.Info("server starting", "addr", addr)
+	for _, ep := range endpoints {
+		slog.Info("endpoint available", "method", ep.method, "path", ep.path)
+	}
 
-	if err := http.ListenAndServe(addr, mux); err != nil {
-		log.Fatal("Server failed to start:", err)
+	server := &http.Server{
+		Addr:    addr,
+		Handler: requestIDMiddleware(loggingMiddleware(gzipMiddleware(metrics.Middleware(corsMiddleware(parseAllowedOrigins(os.Getenv("CORS_ALLOWED_ORIGINS")), limiter.Middleware(prettyJSONMiddleware(mux))))))),
 	}
+
+	// Stop accepting connections on SIGINT/SIGTERM and let in-flight requests
+	// finish before the store is closed
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	go func() {
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			slog.Error("server failed to start", "error", err)
+			os.Exit(1)
+		}
+	}()
+
+	<-ctx.Done()
+	slog.Info("shutting down server")
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		slog.Warn("server shutdown did not complete cleanly", "error", err)
+	}
+
+	if closer, ok := store.(io.Closer); ok {
+		if err := closer.Close(); err != nil {
+			slog.Error("failed to close store", "error", err)
+		}
+	}
+
+	slog.Info("server stopped")
 }
 
-// ===== MODELS =====
+// endpoints lists the routes announced in the startup log.
+var endpoints = []struct{ method, path string }{
+	{"POST", "/strings"},
+	{"POST", "/strings/bulk"},
+	{"POST", "/strings/import"},
+	{"POST", "/strings/batch-get"},
+	{"POST", "/strings/analyze"},
+	{"GET", "/strings"},
+	{"GET", "/strings/export"},
+	{"GET", "/strings/anagrams"},
+	{"GET", "/strings/duplicates"},
+	{"GET", "/strings/stats"},
+	{"GET", "/strings/charset"},
+	{"GET", "/strings/random"},
+	{"GET", "/strings/search?q=..."},
+	{"GET", "/strings/diff?a=...&b=..."},
+	{"GET", "/strings/lcs?a=...&b=..."},
+	{"GET", "/strings/by-id/{id}"},
+	{"GET", "/strings/{value}"},
+	{"HEAD", "/strings/{value}"},
+	{"GET", "/strings/{value}/exists"},
+	{"HEAD", "/strings/{value}/exists"},
+	{"GET", "/strings/{value}/similar"},
+	{"GET", "/strings/{value}/frequency"},
+	{"GET", "/strings/{value}/palindromes"},
+	{"PUT", "/strings/{value}"},
+	{"GET", "/strings/filter-by-natural-language"},
+	{"POST", "/strings/filter-by-natural-language"},
+	{"DELETE", "/strings/{value}"},
+	{"DELETE", "/strings?confirm=true[&filters]"},
+	{"POST", "/strings/purge"},
+	{"GET", "/ready"},
+	{"GET", "/metrics"},
+	{"GET", "/openapi.json"},
+}
 
-type Properties struct {
-	Length                int            `json:"length"`
-	IsPalindrome          bool           `json:"is_palindrome"`
-	UniqueCharacters      int            `json:"unique_characters"`
-	WordCount             int            `json:"word_count"`
-	SHA256Hash            string         `json:"sha256_hash"`
-	CharacterFrequencyMap map[string]int `json:"character_frequency_map"`
+// setupLogger installs a JSON slog handler on stderr as the default logger,
+// at the level named by LOG_LEVEL (debug, info, warn or error; default info).
+func setupLogger() {
+	level := slog.LevelInfo
+	val := os.Getenv("LOG_LEVEL")
+	err := level.UnmarshalText([]byte(val))
+	if val == "" || err != nil {
+		level = slog.LevelInfo
+	}
+
+	slog.SetDefault(slog.New(slog.NewJSONHandler(os.Stderr, &slog.HandlerOptions{Level: level})))
+
+	if val != "" && err != nil {
+		slog.Warn("ignoring invalid LOG_LEVEL", "value", val, "default", "info")
+	}
 }
 
-type StringAnalysis struct {
-	ID         string     `json:"id"`
-	Value      string     `json:"value"`
-	Properties Properties `json:"properties"`
-	CreatedAt  string     `json:"created_at"`
+// shutdownTimeout bounds how long in-flight requests may run after a
+// shutdown signal.
+const shutdownTimeout = 10 * time.Second
+
+// ===== ROUTING =====
+
+// methodHandlers maps each HTTP method a route allows to its handler. It
+// answers any other method with 405 Method Not Allowed and an Allow header
+// listing the allowed ones, so handlers need not check the method.
+type methodHandlers map[string]http.HandlerFunc
+
+func (m methodHandlers) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if handler, ok := m[r.Method]; ok {
+		handler(w, r)
+		return
+	}
+
+	w.Header().Set("Allow", m.allow())
+	respondError(w, http.StatusMethodNotAllowed, "Method not allowed")
 }
 
-func NewStringAnalysis(value string) *StringAnalysis {
-	hash := computeSHA256(value)
+// allow lists the allowed methods, sorted, in Allow header form.
+func (m methodHandlers) allow() string {
+	methods := make([]string, 0, len(m))
+	for method := range m {
+		methods = append(methods, method)
+	}
+	sort.Strings(methods)
+	return strings.Join(methods, ", ")
+}
 
-	return &StringAnalysis{
-		ID:    hash,
-		Value: value,
-		Properties: Properties{
-			Length:                len(value),
-			IsPalindrome:          isPalindrome(value),
-			UniqueCharacters:      countUniqueChars(value),
-			WordCount:             countWords(value),
-			SHA256Hash:            hash,
-			CharacterFrequencyMap: buildFrequencyMap(value),
-		},
-		CreatedAt: fmt.Sprintf("%s", getCurrentTime()),
+// pathEquals matches any of the given escaped paths exactly.
+func pathEquals(paths ...string) func(string) bool {
+	return func(path string) bool {
+		return slices.Contains(paths, path)
 	}
 }
 
-func computeSHA256(s string) string {
-	// Simple hash for demonstration - in production use crypto/sha256
-	hash := 0
-	for _, c := range s {
-		hash = hash*31 + int(c)
+func pathPrefix(prefix string) func(string) bool {
+	return func(path string) bool {
+		return strings.HasPrefix(path, prefix)
 	}
-	return fmt.Sprintf("%x", hash)
 }
 
-func isPalindrome(s string) bool {
-	s = strings.ToLower(s)
-	left, right := 0, len(s)-1
+// pathSuffix matches /strings/{value}{suffix} with a non-empty value, so a
+// stored value named like the sub-route (GET /strings/similar) still reaches
+// /strings/{value}.
+func pathSuffix(suffix string) func(string) bool {
+	const prefix = "/strings/"
+	return func(path string) bool {
+		return strings.HasPrefix(path, prefix) && strings.HasSuffix(path, suffix) &&
+			len(path) > len(prefix)+len(suffix)
+	}
+}
 
-	for left < right {
-		if s[left] != s[right] {
-			return false
+// ===== CONFIG =====
+
+// Config holds settings read from the environment at startup.
+type Config struct {
+	// MaxStringLength is the longest value, in runes, that may be stored.
+	MaxStringLength int
+	// RateLimitRPS and RateLimitBurst bound write requests per client IP.
+	RateLimitRPS   int
+	RateLimitBurst int
+	// MaxEntries caps the memory and file stores; zero means unbounded.
+	MaxEntries int
+	// HashAlgorithm names the digest that becomes each new entry's ID.
+	HashAlgorithm string
+}
+
+const (
+	defaultMaxStringLength = 10000
+	maxBulkBodyBytes       = 10 << 20
+	defaultRateLimitRPS    = 20
+	defaultRateLimitBurst  = 100
+)
+
+func LoadConfig() Config {
+	return Config{
+		MaxStringLength: envInt("MAX_STRING_LENGTH", defaultMaxStringLength),
+		RateLimitRPS:    envInt("RATE_LIMIT_RPS", defaultRateLimitRPS),
+		RateLimitBurst:  envInt("RATE_LIMIT_BURST", defaultRateLimitBurst),
+		MaxEntries:      envInt("MAX_ENTRIES", 0),
+		HashAlgorithm:   envHashAlgorithm("HASH_ALGORITHM"),
+	}
+}
+
+// envHashAlgorithm reads a hash algorithm name from the environment, falling
+// back to defaultHashAlgorithm when the variable is unset or unknown.
+func envHashAlgorithm(name string) string {
+	val := os.Getenv(name)
+	if val == "" {
+		return defaultHashAlgorithm
+	}
+
+	if _, ok := hashAlgorithms[val]; !ok {
+		slog.Warn("ignoring invalid environment variable", "name", name, "value", val, "default", defaultHashAlgorithm)
+		return defaultHashAlgorithm
+	}
+
+	return val
+}
+
+// envInt reads a positive integer from the environment, falling back to def
+// when the variable is unset or invalid.
+func envInt(name string, def int) int {
+	val := os.Getenv(name)
+	if val == "" {
+		return def
+	}
+
+	i, err := strconv.Atoi(val)
+	if err != nil || i <= 0 {
+		slog.Warn("ignoring invalid environment variable", "name", name, "value", val, "default", def)
+		return def
+	}
+
+	return i
+}
+
+// ===== MIDDLEWARE =====
+
+// parseAllowedOrigins splits a comma-separated CORS_ALLOWED_ORIGINS value.
+func parseAllowedOrigins(raw string) map[string]bool {
+	origins := make(map[string]bool)
+	for _, origin := range strings.Split(raw, ",") {
+		if origin = strings.TrimSpace(origin); origin != "" {
+			origins[origin] = true
 		}
-		left++
-		right--
 	}
+	return origins
+}
 
-	return true
+// corsMiddleware sets CORS headers on every response and answers preflight
+// requests. With no allowed origins configured any origin is accepted via
+// "*"; otherwise only listed origins are echoed back.
+func corsMiddleware(allowed map[string]bool, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if len(allowed) == 0 {
+			w.Header().Set("Access-Control-Allow-Origin", "*")
+		} else {
+			w.Header().Add("Vary", "Origin")
+			if origin := r.Header.Get("Origin"); allowed[origin] {
+				w.Header().Set("Access-Control-Allow-Origin", origin)
+			}
+		}
+		w.Header().Set("Access-Control-Allow-Methods", "GET, HEAD, POST, PUT, DELETE, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, X-Request-ID")
+		w.Header().Set("Access-Control-Expose-Headers", "X-Request-ID")
+
+		if r.Method == http.MethodOptions {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
 }
 
-func countUniqueChars(s string) int {
-	seen := make(map[rune]bool)
-	for _, char := range s {
-		seen[char] = true
+// rateLimiterIdleTTL is how long a client's limiter survives without
+// requests before the cleanup loop drops it.
+const rateLimiterIdleTTL = 3 * time.Minute
+
+// IPRateLimiter hands out a token-bucket limiter per client IP.
+type IPRateLimiter struct {
+	mu      sync.Mutex
+	clients map[string]*clientLimiter
+	limit   rate.Limit
+	burst   int
+}
+
+type clientLimiter struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+// NewIPRateLimiter allows each IP limit requests per second with bursts of up
+// to burst. It starts a goroutine, running for the life of the process, that
+// drops limiters idle for longer than rateLimiterIdleTTL.
+func NewIPRateLimiter(limit rate.Limit, burst int) *IPRateLimiter {
+	l := &IPRateLimiter{
+		clients: make(map[string]*clientLimiter),
+		limit:   limit,
+		burst:   burst,
 	}
-	return len(seen)
+	go l.cleanup(time.Minute)
+	return l
 }
 
-func countWords(s string) int {
-	words := strings.Fields(s)
-	return len(words)
+func (l *IPRateLimiter) get(ip string) *rate.Limiter {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	client, ok := l.clients[ip]
+	if !ok {
+		client = &clientLimiter{limiter: rate.NewLimiter(l.limit, l.burst)}
+		l.clients[ip] = client
+	}
+	client.lastSeen = time.Now()
+
+	return client.limiter
 }
 
-func buildFrequencyMap(s string) map[string]int {
-	freq := make(map[string]int)
-	for _, char := range s {
-		charStr := string(char)
-		freq[charStr]++
+func (l *IPRateLimiter) cleanup(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		l.mu.Lock()
+		for ip, client := range l.clients {
+			if time.Since(client.lastSeen) > rateLimiterIdleTTL {
+				delete(l.clients, ip)
+			}
+		}
+		l.mu.Unlock()
 	}
-	return freq
 }
 
-func getCurrentTime() string {
-	return "2025-10-21T10:00:00Z"
+// Middleware rate-limits write requests (POST, PUT, DELETE) by client IP and
+// answers 429 with a Retry-After header once a client runs out of tokens.
+// Reads are not limited.
+func (l *IPRateLimiter) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost && r.Method != http.MethodPut && r.Method != http.MethodDelete {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		// RemoteAddr carries the client's ephemeral port; key on the host only
+		ip, _, err := net.SplitHostPort(r.RemoteAddr)
+		if err != nil {
+			ip = r.RemoteAddr
+		}
+
+		reservation := l.get(ip).Reserve()
+		if delay := reservation.Delay(); delay > 0 {
+			reservation.Cancel()
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(delay.Seconds()))))
+			respondError(w, http.StatusTooManyRequests, "Rate limit exceeded")
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
 }
 
-// ===== STORAGE =====
+// statusRecorder captures the status code written by a handler.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
 
-type MemoryStore struct {
-	strings map[string]*StringAnalysis
-	hashes  map[string]string
+func (rec *statusRecorder) WriteHeader(status int) {
+	rec.status = status
+	rec.ResponseWriter.WriteHeader(status)
 }
 
-func NewMemoryStore() *MemoryStore {
-	return &MemoryStore{
-		strings: make(map[string]*StringAnalysis),
-		hashes:  make(map[string]string),
+func (rec *statusRecorder) Flush() {
+	if flusher, ok := rec.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
 	}
 }
 
-func (s *MemoryStore) Create(analysis *StringAnalysis) error {
-	if _, exists := s.strings[analysis.Value]; exists {
-		return fmt.Errorf("already exists")
+func (rec *statusRecorder) Unwrap() http.ResponseWriter {
+	return rec.ResponseWriter
+}
+
+// loggingMiddleware logs the method, path, status and duration of every
+// request.
+func loggingMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+
+		next.ServeHTTP(rec, r)
+
+		slog.Info("request",
+			"request_id", RequestIDFromContext(r.Context()),
+			"method", r.Method,
+			"path", r.URL.Path,
+			"status", rec.status,
+			"duration_ms", float64(time.Since(start).Microseconds())/1000,
+		)
+	})
+}
+
+// gzipMinSize is the smallest response body worth compressing; below it the
+// gzip framing costs more than it saves.
+const gzipMinSize = 1024
+
+// gzipMiddleware compresses responses for clients that send
+// Accept-Encoding: gzip. Bodies are buffered until gzipMinSize bytes or a
+// Flush, so tiny responses go out uncompressed and streams start compressing
+// as soon as they flush.
+func gzipMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Vary", "Accept-Encoding")
+		if r.Method == http.MethodHead || !acceptsGzip(r.Header.Get("Accept-Encoding")) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		gw := &gzipResponseWriter{ResponseWriter: w, status: http.StatusOK}
+		defer gw.Close()
+
+		next.ServeHTTP(gw, r)
+	})
+}
+
+// acceptsGzip reports whether an Accept-Encoding header lists gzip without
+// q=0.
+func acceptsGzip(header string) bool {
+	for _, part := range strings.Split(header, ",") {
+		coding, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if !strings.EqualFold(strings.TrimSpace(coding), "gzip") {
+			continue
+		}
+		if q, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			weight, err := strconv.ParseFloat(q, 64)
+			return err == nil && weight > 0
+		}
+		return true
 	}
+	return false
+}
 
-	s.strings[analysis.Value] = analysis
-	s.hashes[analysis.ID] = analysis.Value
+// gzipResponseWriter defers the compression decision until it has seen
+// enough of the body or the handler flushes.
+type gzipResponseWriter struct {
+	http.ResponseWriter
+	status  int
+	buf     []byte
+	decided bool
+	gz      *gzip.Writer
+}
 
-	return nil
+func (gw *gzipResponseWriter) WriteHeader(status int) {
+	if !gw.decided {
+		gw.status = status
+	}
 }
 
-func (s *MemoryStore) Get(value string) (*StringAnalysis, error) {
-	analysis, exists := s.strings[value]
-	if !exists {
-		return nil, fmt.Errorf("not found")
+func (gw *gzipResponseWriter) Write(p []byte) (int, error) {
+	if !gw.decided {
+		gw.buf = append(gw.buf, p...)
+		if len(gw.buf) < gzipMinSize {
+			return len(p), nil
+		}
+		if err := gw.decide(true); err != nil {
+			return 0, err
+		}
+		return len(p), nil
 	}
 
-	return analysis, nil
+	if gw.gz != nil {
+		return gw.gz.Write(p)
+	}
+	return gw.ResponseWriter.Write(p)
 }
 
-func (s *MemoryStore) GetAll(filters map[string]interface{}) []*StringAnalysis {
-	var results []*StringAnalysis
+// decide sends the headers and buffered body, compressing them when want is
+// set and the response is eligible.
+func (gw *gzipResponseWriter) decide(want bool) error {
+	gw.decided = true
 
-	for _, analysis := range s.strings {
-		if matchesFilters(analysis, filters) {
-			results = append(results, analysis)
+	header := gw.Header()
+	if want && gw.compressible() {
+		header.Set("Content-Encoding", "gzip")
+		header.Del("Content-Length")
+		// The compressed bytes differ, so a strong validator no longer applies
+		if etag := header.Get("ETag"); strings.HasPrefix(etag, `"`) {
+			header.Set("ETag", "W/"+etag)
 		}
+		gw.gz = gzip.NewWriter(gw.ResponseWriter)
 	}
 
-	return results
+	gw.ResponseWriter.WriteHeader(gw.status)
+
+	buf := gw.buf
+	gw.buf = nil
+	if len(buf) == 0 {
+		return nil
+	}
+	if gw.gz != nil {
+		_, err := gw.gz.Write(buf)
+		return err
+	}
+	_, err := gw.ResponseWriter.Write(buf)
+	return err
 }
 
-func (s *MemoryStore) Delete(value string) error {
-	analysis, exists := s.strings[value]
-	if !exists {
-		return fmt.Errorf("not found")
+// compressible rejects bodiless statuses and content that is already
+// encoded or compressed.
+func (gw *gzipResponseWriter) compressible() bool {
+	if gw.status == http.StatusNoContent || gw.status == http.StatusNotModified || gw.status < http.StatusOK {
+		return false
 	}
 
-	delete(s.strings, value)
-	delete(s.hashes, analysis.ID)
+	header := gw.Header()
+	if header.Get("Content-Encoding") != "" {
+		return false
+	}
+
+	contentType := header.Get("Content-Type")
+	if contentType == "" {
+		contentType = http.DetectContentType(gw.buf)
+	}
+	for _, prefix := range []string{"image/", "video/", "audio/", "application/gzip", "application/zip", "application/x-gzip"} {
+		if strings.HasPrefix(contentType, prefix) && contentType != "image/svg+xml" {
+			return false
+		}
+	}
+	return true
+}
+
+func (gw *gzipResponseWriter) Flush() {
+	if !gw.decided {
+		gw.decide(true)
+	}
+	if gw.gz != nil {
+		gw.gz.Flush()
+	}
+	if flusher, ok := gw.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
 
+// Close sends anything still buffered uncompressed, since it never reached
+// gzipMinSize, and finishes the gzip stream.
+func (gw *gzipResponseWriter) Close() error {
+	if !gw.decided {
+		if err := gw.decide(false); err != nil {
+			return err
+		}
+	}
+	if gw.gz != nil {
+		return gw.gz.Close()
+	}
 	return nil
 }
 
-func matchesFilters(analysis *StringAnalysis, filters map[string]interface{}) bool {
-	if val, ok := filters["is_palindrome"].(bool); ok {
-		if analysis.Properties.IsPalindrome != val {
+func (gw *gzipResponseWriter) Unwrap() http.ResponseWriter {
+	return gw.ResponseWriter
+}
+
+// prettyJSONWriter marks a response whose JSON respondJSON should indent.
+type prettyJSONWriter struct {
+	http.ResponseWriter
+}
+
+func (pw *prettyJSONWriter) Flush() {
+	if flusher, ok := pw.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func (pw *prettyJSONWriter) Unwrap() http.ResponseWriter {
+	return pw.ResponseWriter
+}
+
+// prettyJSONMiddleware wraps the writer of requests carrying ?pretty=true so
+// respondJSON indents its output for reading in a browser. It must sit
+// directly around the routes, since respondJSON only checks the writer it is
+// given, not the ones beneath it.
+func prettyJSONMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("pretty") == "true" {
+			w = &prettyJSONWriter{ResponseWriter: w}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// requestIDKey is the context key under which requestIDMiddleware stores the
+// request ID.
+type requestIDKey struct{}
+
+// maxRequestIDLength bounds client-supplied request IDs, which end up in logs.
+const maxRequestIDLength = 128
+
+// requestIDMiddleware tags every request with an ID taken from the
+// X-Request-ID header, or a generated UUID when the header is absent or
+// unusable. The ID is stored in the request context and echoed in the
+// response.
+func requestIDMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get("X-Request-ID")
+		if !validRequestID(id) {
+			id = newRequestID()
+		}
+
+		w.Header().Set("X-Request-ID", id)
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), requestIDKey{}, id)))
+	})
+}
+
+// RequestIDFromContext returns the request ID set by requestIDMiddleware, or
+// "" if there is none.
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// validRequestID accepts non-empty IDs of printable ASCII without spaces, so
+// a client cannot forge log lines.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] <= ' ' || id[i] > '~' {
 			return false
 		}
 	}
+	return true
+}
+
+// newRequestID returns a random version 4 UUID.
+func newRequestID() string {
+	var b [16]byte
+	rand.Read(b[:])
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
+
+// ===== METRICS =====
+
+// Metrics holds the counters exposed at /metrics in the Prometheus text
+// format.
+type Metrics struct {
+	mu         sync.Mutex
+	requests   int64
+	byEndpoint map[endpointKey]int64
+	created    int64
+	deleted    int64
+}
+
+type endpointKey struct {
+	method   string
+	endpoint string
+}
+
+func NewMetrics() *Metrics {
+	return &Metrics{byEndpoint: make(map[endpointKey]int64)}
+}
+
+func (m *Metrics) StringsCreated(n int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.created += int64(n)
+}
+
+func (m *Metrics) StringsDeleted(n int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.deleted += int64(n)
+}
+
+// Middleware counts every request, labelled by method and route.
+func (m *Metrics) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := endpointKey{method: methodLabel(r.Method), endpoint: routeLabel(r.URL.Path)}
+
+		m.mu.Lock()
+		m.requests++
+		m.byEndpoint[key]++
+		m.mu.Unlock()
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Handler serves the metrics, reading the stored-string gauge from store.
+func (m *Metrics) Handler(store Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		m.mu.Lock()
+		keys := make([]endpointKey, 0, len(m.byEndpoint))
+		for key := range m.byEndpoint {
+			keys = append(keys, key)
+		}
+		sort.Slice(keys, func(i, j int) bool {
+			if keys[i].endpoint != keys[j].endpoint {
+				return keys[i].endpoint < keys[j].endpoint
+			}
+			return keys[i].method < keys[j].method
+		})
+
+		var b strings.Builder
+		fmt.Fprintf(&b, "# HELP string_analyzer_requests_total Total HTTP requests.\n")
+		fmt.Fprintf(&b, "# TYPE string_analyzer_requests_total counter\n")
+		fmt.Fprintf(&b, "string_analyzer_requests_total %d\n", m.requests)
+		fmt.Fprintf(&b, "# HELP string_analyzer_endpoint_requests_total HTTP requests by method and endpoint.\n")
+		fmt.Fprintf(&b, "# TYPE string_analyzer_endpoint_requests_total counter\n")
+		for _, key := range keys {
+			fmt.Fprintf(&b, "string_analyzer_endpoint_requests_total{method=%q,endpoint=%q} %d\n", key.method, key.endpoint, m.byEndpoint[key])
+		}
+		fmt.Fprintf(&b, "# HELP string_analyzer_strings_created_total Strings created.\n")
+		fmt.Fprintf(&b, "# TYPE string_analyzer_strings_created_total counter\n")
+		fmt.Fprintf(&b, "string_analyzer_strings_created_total %d\n", m.created)
+		fmt.Fprintf(&b, "# HELP string_analyzer_strings_deleted_total Strings deleted.\n")
+		fmt.Fprintf(&b, "# TYPE string_analyzer_strings_deleted_total counter\n")
+		fmt.Fprintf(&b, "string_analyzer_strings_deleted_total %d\n", m.deleted)
+		m.mu.Unlock()
+
+		fmt.Fprintf(&b, "# HELP string_analyzer_strings_stored Strings currently stored.\n")
+		fmt.Fprintf(&b, "# TYPE string_analyzer_strings_stored gauge\n")
+		fmt.Fprintf(&b, "string_analyzer_strings_stored %d\n", store.Len())
+
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(b.String()))
+	}
+}
+
+// methodLabel passes the standard HTTP methods through and maps anything
+// else to "other", so clients cannot mint metric labels with made-up methods.
+func methodLabel(method string) string {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch,
+		http.MethodDelete, http.MethodConnect, http.MethodOptions, http.MethodTrace:
+		return method
+	}
+	return "other"
+}
+
+// routeLabel maps a request path to its route pattern so stored values do
+// not become metric labels.
+func routeLabel(path string) string {
+	switch path {
+	case "/", "/health", "/ready", "/metrics", "/openapi.json", "/strings", "/strings/bulk", "/strings/import", "/strings/batch-get", "/strings/purge", "/strings/analyze", "/strings/export", "/strings/anagrams",
+		"/strings/duplicates", "/strings/stats", "/strings/charset", "/strings/random", "/strings/search", "/strings/diff", "/strings/lcs", "/strings/filter-by-natural-language":
+		return path
+	}
+
+	if !strings.HasPrefix(path, "/strings/") {
+		return "other"
+	}
+
+	if strings.HasPrefix(path, "/strings/by-id/") {
+		return "/strings/by-id/{id}"
+	}
+
+	for _, suffix := range []string{"/similar", "/exists", "/frequency", "/palindromes"} {
+		if strings.HasSuffix(path, suffix) {
+			return "/strings/{value}" + suffix
+		}
+	}
+
+	return "/strings/{value}"
+}
+
+// ===== MODELS =====
+
+// Length counts runes; ByteLength counts the UTF-8 encoded bytes.
+type Properties struct {
+	Length                int            `json:"length"`
+	ByteLength            int            `json:"byte_length"`
+	GraphemeCount         int            `json:"grapheme_count"`
+	IsPalindrome          bool           `json:"is_palindrome"`
+	PalindromeMode        string         `json:"palindrome_mode"`
+	UniqueCharacters      int            `json:"unique_characters"`
+	WordCount             int            `json:"word_count"`
+	SHA256Hash            string         `json:"sha256_hash"`
+	Hash                  string         `json:"hash"`
+	HashAlgorithm         string         `json:"hash_algorithm"`
+	CharacterFrequencyMap map[string]int `json:"character_frequency_map"`
+	VowelCount            int            `json:"vowel_count"`
+	ConsonantCount        int            `json:"consonant_count"`
+	DigitCount            int            `json:"digit_count"`
+	WhitespaceCount       int            `json:"whitespace_count"`
+	Reversed              string         `json:"reversed"`
+	Entropy               float64        `json:"entropy"`
+	LongestWord           string         `json:"longest_word"`
+	ShortestWord          string         `json:"shortest_word"`
+	LineCount             int            `json:"line_count"`
+	SentenceCount         int            `json:"sentence_count"`
+	IsPangram             bool           `json:"is_pangram"`
+	MostFrequentChar      string         `json:"most_frequent_char"`
+	MostFrequentCount     int            `json:"most_frequent_count"`
+	UppercaseCount        int            `json:"uppercase_count"`
+	LowercaseCount        int            `json:"lowercase_count"`
+	WordFrequencyMap      map[string]int `json:"word_frequency_map,omitempty"`
+	Uppercase             string         `json:"uppercase"`
+	Lowercase             string         `json:"lowercase"`
+	TitleCase             string         `json:"title_case"`
+	IsRepeating           bool           `json:"is_repeating"`
+	RepeatUnit            string         `json:"repeat_unit"`
+	IsNumeric             bool           `json:"is_numeric"`
+	IsAlpha               bool           `json:"is_alpha"`
+	IsAlphanumeric        bool           `json:"is_alphanumeric"`
+	HasWhitespace         bool           `json:"has_whitespace"`
+}
+
+type StringAnalysis struct {
+	ID         string     `json:"id"`
+	Value      string     `json:"value"`
+	Properties Properties `json:"properties"`
+	CreatedAt  string     `json:"created_at"`
+	// UpdatedAt starts equal to CreatedAt and is refreshed whenever the
+	// entry is re-analyzed or replaced.
+	UpdatedAt string `json:"updated_at"`
+	// DeletedAt marks a soft-deleted entry (a tombstone). Tombstones are
+	// hidden from reads unless include_deleted is requested and are removed
+	// for good by Purge.
+	DeletedAt string `json:"deleted_at,omitempty"`
+}
+
+// Deleted reports whether the entry is a tombstone.
+func (a *StringAnalysis) Deleted() bool {
+	return a.DeletedAt != ""
+}
+
+// AnalysisOptions controls which properties NewStringAnalysis computes. The
+// zero value computes all of them.
+type AnalysisOptions struct {
+	// Fields lists the JSON names of the properties to compute. Empty means
+	// every property; unlisted properties are left at their zero value.
+	Fields []string
+	// PalindromeMode selects how is_palindrome compares characters. Empty
+	// means defaultPalindromeMode.
+	PalindromeMode string
+	// HashAlgorithm selects the digest used for the ID and the hash
+	// property. Empty means defaultHashAlgorithm.
+	HashAlgorithm string
+}
+
+// wants reports whether any of the named properties was requested.
+func (o AnalysisOptions) wants(names ...string) bool {
+	if len(o.Fields) == 0 {
+		return true
+	}
+	for _, field := range o.Fields {
+		for _, name := range names {
+			if field == name {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func NewStringAnalysis(value string, opts AnalysisOptions) *StringAnalysis {
+	algorithm := opts.HashAlgorithm
+	if algorithm == "" {
+		algorithm = defaultHashAlgorithm
+	}
+
+	// The hash is always needed: it is the entry's ID
+	id := computeHash(value, algorithm)
+	props := Properties{Hash: id, HashAlgorithm: algorithm}
+
+	// sha256_hash stays a SHA-256 digest whatever the configured algorithm
+	if algorithm == "sha256" {
+		props.SHA256Hash = id
+	} else if opts.wants("sha256_hash") {
+		props.SHA256Hash = computeSHA256(value)
+	}
+
+	if opts.wants("length") {
+		props.Length = utf8.RuneCountInString(value)
+	}
+	if opts.wants("byte_length") {
+		props.ByteLength = len(value)
+	}
+	if opts.wants("grapheme_count") {
+		props.GraphemeCount = uniseg.GraphemeClusterCount(value)
+	}
+	if opts.wants("is_palindrome", "palindrome_mode") {
+		mode := opts.PalindromeMode
+		if mode == "" {
+			mode = defaultPalindromeMode
+		}
+		props.IsPalindrome = isPalindrome(value, mode)
+		props.PalindromeMode = mode
+	}
+	if opts.wants("unique_characters") {
+		props.UniqueCharacters = countUniqueChars(value)
+	}
+	if opts.wants("word_count") {
+		props.WordCount = countWords(value)
+	}
+	if opts.wants("character_frequency_map", "entropy", "most_frequent_char", "most_frequent_count") {
+		freq := buildFrequencyMap(value)
+		props.CharacterFrequencyMap = freq
+		props.Entropy = shannonEntropy(freq)
+		props.MostFrequentChar, props.MostFrequentCount = mostFrequentChar(freq)
+	}
+	if opts.wants("vowel_count", "consonant_count", "digit_count", "whitespace_count", "uppercase_count", "lowercase_count") {
+		classes := countCharClasses(value)
+		props.VowelCount = classes.vowels
+		props.ConsonantCount = classes.consonants
+		props.DigitCount = classes.digits
+		props.WhitespaceCount = classes.whitespace
+		props.UppercaseCount = classes.uppercase
+		props.LowercaseCount = classes.lowercase
+	}
+	if opts.wants("reversed") {
+		props.Reversed = reverseString(value)
+	}
+	if opts.wants("longest_word", "shortest_word") {
+		props.LongestWord, props.ShortestWord = longestAndShortestWords(value)
+	}
+	if opts.wants("line_count") {
+		props.LineCount = countLines(value)
+	}
+	if opts.wants("sentence_count") {
+		props.SentenceCount = countSentences(value)
+	}
+	if opts.wants("is_pangram") {
+		props.IsPangram = isPangram(value)
+	}
+	if opts.wants("word_frequency_map") {
+		props.WordFrequencyMap = buildWordFrequencyMap(value)
+	}
+	if opts.wants("uppercase") {
+		props.Uppercase = strings.ToUpper(value)
+	}
+	if opts.wants("lowercase") {
+		props.Lowercase = strings.ToLower(value)
+	}
+	if opts.wants("title_case") {
+		props.TitleCase = titleCase(value)
+	}
+	if opts.wants("is_repeating", "repeat_unit") {
+		props.RepeatUnit = repeatUnit(value)
+		props.IsRepeating = props.RepeatUnit != ""
+	}
+	if opts.wants("is_numeric", "is_alpha", "is_alphanumeric", "has_whitespace") {
+		summary := summarizeCharClasses(value)
+		props.IsNumeric = summary.numeric
+		props.IsAlpha = summary.alpha
+		props.IsAlphanumeric = summary.alphanumeric
+		props.HasWhitespace = summary.whitespace
+	}
+
+	now := getCurrentTime()
+	return &StringAnalysis{
+		ID:         id,
+		Value:      value,
+		Properties: props,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+}
+
+// propertyNames lists the JSON names of every Properties field, in
+// declaration order, for validating ?fields=.
+var propertyNames = func() []string {
+	t := reflect.TypeOf(Properties{})
+	names := make([]string, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		names = append(names, name)
+	}
+	return names
+}()
+
+// parseFields reads the comma-separated ?fields= parameter. It returns nil
+// when the parameter is absent and rejects unknown property names.
+func parseFields(query url.Values) ([]string, error) {
+	if !query.Has("fields") {
+		return nil, nil
+	}
+
+	var fields []string
+	for _, field := range strings.Split(query.Get("fields"), ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		if !slices.Contains(propertyNames, field) {
+			return nil, fmt.Errorf("unknown field %q: must be one of %s", field, strings.Join(propertyNames, ", "))
+		}
+		fields = append(fields, field)
+	}
+
+	if len(fields) == 0 {
+		return nil, errors.New("fields must name at least one property")
+	}
+	return fields, nil
+}
+
+// PartialAnalysis is a StringAnalysis trimmed to the properties requested
+// with ?fields=.
+type PartialAnalysis struct {
+	ID         string                     `json:"id"`
+	Value      string                     `json:"value"`
+	Properties map[string]json.RawMessage `json:"properties"`
+	CreatedAt  string                     `json:"created_at"`
+	UpdatedAt  string                     `json:"updated_at"`
+}
+
+// selectProperties returns analysis unchanged when fields is empty, and
+// otherwise a PartialAnalysis holding only the named properties.
+func selectProperties(analysis *StringAnalysis, fields []string) interface{} {
+	if len(fields) == 0 {
+		return analysis
+	}
+
+	raw, err := json.Marshal(analysis.Properties)
+	if err != nil {
+		return analysis
+	}
+	var all map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &all); err != nil {
+		return analysis
+	}
+
+	props := make(map[string]json.RawMessage, len(fields))
+	for _, field := range fields {
+		// omitempty fields such as word_frequency_map may be absent
+		if val, ok := all[field]; ok {
+			props[field] = val
+		}
+	}
+
+	return &PartialAnalysis{
+		ID:         analysis.ID,
+		Value:      analysis.Value,
+		Properties: props,
+		CreatedAt:  analysis.CreatedAt,
+		UpdatedAt:  analysis.UpdatedAt,
+	}
+}
+
+func computeSHA256(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
+
+// backfillHash fills in hash and hash_algorithm for entries stored before
+// they existed, when the ID was always the SHA-256 digest.
+func backfillHash(analysis *StringAnalysis) {
+	if analysis.Properties.HashAlgorithm == "" {
+		analysis.Properties.Hash = analysis.ID
+		analysis.Properties.HashAlgorithm = "sha256"
+	}
+}
+
+// hashAlgorithms are the digests HASH_ALGORITHM can select. MD5 and SHA-1
+// exist for systems that still key on them, not for security.
+var hashAlgorithms = map[string]func() hash.Hash{
+	"md5":    md5.New,
+	"sha1":   sha1.New,
+	"sha256": sha256.New,
+	"sha512": sha512.New,
+}
+
+const defaultHashAlgorithm = "sha256"
+
+// computeHash returns the hex digest of s under algorithm, which must be a
+// key of hashAlgorithms.
+func computeHash(s, algorithm string) string {
+	h := hashAlgorithms[algorithm]()
+	h.Write([]byte(s))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Palindrome modes. strict compares every character exactly, ci ignores
+// case, and alphanumeric also skips everything but letters and digits.
+const (
+	palindromeStrict          = "strict"
+	palindromeCaseInsensitive = "ci"
+	palindromeAlphanumeric    = "alphanumeric"
+)
+
+// defaultPalindromeMode is the phrase-palindrome check the API has always
+// applied.
+const defaultPalindromeMode = palindromeAlphanumeric
+
+var palindromeModes = []string{palindromeStrict, palindromeCaseInsensitive, palindromeAlphanumeric}
+
+// parsePalindromeMode reads ?palindrome_mode=, returning "" when it is absent.
+func parsePalindromeMode(query url.Values) (string, error) {
+	mode := query.Get("palindrome_mode")
+	if mode != "" && !slices.Contains(palindromeModes, mode) {
+		return "", errors.New("invalid palindrome_mode: must be one of " + strings.Join(palindromeModes, ", "))
+	}
+	return mode, nil
+}
+
+func isPalindrome(s, mode string) bool {
+	runes := normalizeForPalindrome(s, mode)
+	left, right := 0, len(runes)-1
+
+	for left < right {
+		if runes[left] != runes[right] {
+			return false
+		}
+		left++
+		right--
+	}
+
+	return true
+}
+
+// normalizeForPalindrome returns the runes of s that the palindrome check
+// compares under mode. In alphanumeric mode s is lowercased and everything
+// that is not a letter or digit dropped, so phrase palindromes like
+// "A man, a plan..." compare equal.
+func normalizeForPalindrome(s, mode string) []rune {
+	switch mode {
+	case palindromeStrict:
+		return []rune(s)
+	case palindromeCaseInsensitive:
+		return []rune(strings.ToLower(s))
+	}
+
+	runes := make([]rune, 0, len(s))
+	for _, r := range s {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			runes = append(runes, unicode.ToLower(r))
+		}
+	}
+	return runes
+}
+
+// isPangram reports whether s contains every English letter a–z, ignoring
+// case.
+func isPangram(s string) bool {
+	seen := make(map[rune]bool)
+	for _, char := range strings.ToLower(s) {
+		if char >= 'a' && char <= 'z' {
+			seen[char] = true
+		}
+	}
+	return len(seen) == 26
+}
+
+func countUniqueChars(s string) int {
+	seen := make(map[rune]bool)
+	for _, char := range s {
+		seen[char] = true
+	}
+	return len(seen)
+}
+
+func countWords(s string) int {
+	words := strings.Fields(s)
+	return len(words)
+}
+
+// buildWordFrequencyMap counts whitespace-separated words case-insensitively.
+// Punctuation stays attached to its word, so "dog" and "dog." count
+// separately. Returns nil when there are no words.
+func buildWordFrequencyMap(s string) map[string]int {
+	words := strings.Fields(s)
+	if len(words) == 0 {
+		return nil
+	}
+
+	freq := make(map[string]int, len(words))
+	for _, word := range words {
+		freq[strings.ToLower(word)]++
+	}
+	return freq
+}
+
+// titleCase title-cases the first letter of each whitespace-separated word and
+// lowercases the rest. It works rune by rune with unicode.ToTitle, so digraphs
+// such as "ǆ" become "ǅ" rather than "Ǆ" and non-Latin scripts are handled.
+func titleCase(s string) string {
+	var b strings.Builder
+	b.Grow(len(s))
+
+	wordStart := true
+	for _, r := range s {
+		switch {
+		case unicode.IsSpace(r):
+			wordStart = true
+			b.WriteRune(r)
+		case wordStart && unicode.IsLetter(r):
+			wordStart = false
+			b.WriteRune(unicode.ToTitle(r))
+		case unicode.IsLetter(r):
+			b.WriteRune(unicode.ToLower(r))
+		default:
+			b.WriteRune(r)
+		}
+	}
+
+	return b.String()
+}
+
+// repeatUnit returns the shortest substring that tiles s when repeated at
+// least twice, or "" if there is none. s is a rotation of itself by p runes
+// exactly when it has period p, so the first occurrence of s inside s+s after
+// the first rune gives the smallest period. Starting the search one whole
+// rune in keeps the unit on rune boundaries.
+func repeatUnit(s string) string {
+	if utf8.RuneCountInString(s) < 2 {
+		return ""
+	}
+
+	_, width := utf8.DecodeRuneInString(s)
+	period := strings.Index((s + s)[width:], s) + width
+	if period < len(s) {
+		return s[:period]
+	}
+	return ""
+}
+
+func buildFrequencyMap(s string) map[string]int {
+	freq := make(map[string]int)
+	for _, char := range s {
+		charStr := string(char)
+		freq[charStr]++
+	}
+	return freq
+}
+
+// longestAndShortestWords returns the longest and shortest whitespace-separated
+// words in s, measured in runes. Ties go to the first occurrence; both are
+// empty when s has no words.
+func longestAndShortestWords(s string) (string, string) {
+	words := strings.Fields(s)
+	if len(words) == 0 {
+		return "", ""
+	}
+
+	longest, shortest := words[0], words[0]
+	for _, word := range words[1:] {
+		n := utf8.RuneCountInString(word)
+		if n > utf8.RuneCountInString(longest) {
+			longest = word
+		}
+		if n < utf8.RuneCountInString(shortest) {
+			shortest = word
+		}
+	}
+
+	return longest, shortest
+}
+
+// countLines returns the number of newline-separated lines. A trailing
+// newline does not start a new line, and the empty string has no lines.
+func countLines(s string) int {
+	if s == "" {
+		return 0
+	}
+	s = strings.TrimSuffix(s, "\n")
+	return strings.Count(s, "\n") + 1
+}
+
+// countSentences counts runs of text containing at least one letter or digit
+// that end in '.', '!' or '?'. Consecutive terminators such as "!!" close a
+// single sentence, and trailing text without a terminator counts as one.
+func countSentences(s string) int {
+	count := 0
+	hasContent := false
+
+	for _, r := range s {
+		switch {
+		case r == '.' || r == '!' || r == '?':
+			if hasContent {
+				count++
+				hasContent = false
+			}
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			hasContent = true
+		}
+	}
+
+	if hasContent {
+		count++
+	}
+
+	return count
+}
+
+// shannonEntropy returns the entropy of a frequency map in bits per
+// character, rounded to four decimal places.
+func shannonEntropy(freq map[string]int) float64 {
+	total := 0
+	for _, count := range freq {
+		total += count
+	}
+	if total == 0 {
+		return 0
+	}
+
+	entropy := 0.0
+	for _, count := range freq {
+		p := float64(count) / float64(total)
+		entropy -= p * math.Log2(p)
+	}
+
+	return math.Round(entropy*10000) / 10000
+}
+
+// reverseString reverses s by rune so multi-byte characters stay intact.
+func reverseString(s string) string {
+	runes := []rune(s)
+	for i, j := 0, len(runes)-1; i < j; i, j = i+1, j-1 {
+		runes[i], runes[j] = runes[j], runes[i]
+	}
+	return string(runes)
+}
+
+type charClassCounts struct {
+	vowels     int
+	consonants int
+	digits     int
+	whitespace int
+	uppercase  int
+	lowercase  int
+}
+
+// countCharClasses tallies vowels (a/e/i/o/u, any case), consonants (other
+// letters), digits, whitespace and upper/lowercase letters in a single pass
+// over the runes.
+func countCharClasses(s string) charClassCounts {
+	var c charClassCounts
+	for _, r := range s {
+		if unicode.IsUpper(r) {
+			c.uppercase++
+		} else if unicode.IsLower(r) {
+			c.lowercase++
+		}
+
+		switch {
+		case strings.ContainsRune("aeiou", unicode.ToLower(r)):
+			c.vowels++
+		case unicode.IsLetter(r):
+			c.consonants++
+		case unicode.IsDigit(r):
+			c.digits++
+		case unicode.IsSpace(r):
+			c.whitespace++
+		}
+	}
+	return c
+}
+
+type charClassSummary struct {
+	numeric      bool
+	alpha        bool
+	alphanumeric bool
+	whitespace   bool
+}
+
+// summarizeCharClasses reports in a single pass over the runes whether s is
+// all digits, all letters or all letters and digits, and whether it contains
+// any whitespace. The empty string has no characters to qualify it, so every
+// flag is false for it.
+func summarizeCharClasses(s string) charClassSummary {
+	if s == "" {
+		return charClassSummary{}
+	}
+
+	c := charClassSummary{numeric: true, alpha: true, alphanumeric: true}
+	for _, r := range s {
+		letter, digit := unicode.IsLetter(r), unicode.IsDigit(r)
+		if !digit {
+			c.numeric = false
+		}
+		if !letter {
+			c.alpha = false
+		}
+		if !letter && !digit {
+			c.alphanumeric = false
+		}
+		if unicode.IsSpace(r) {
+			c.whitespace = true
+		}
+	}
+	return c
+}
+
+// CharCount is one entry of a character frequency map.
+type CharCount struct {
+	Char  string `json:"char"`
+	Count int    `json:"count"`
+}
+
+// sortedFrequencies flattens freq into entries ordered by count descending,
+// then by character.
+func sortedFrequencies(freq map[string]int) []CharCount {
+	counts := make([]CharCount, 0, len(freq))
+	for char, count := range freq {
+		counts = append(counts, CharCount{Char: char, Count: count})
+	}
+	sort.Slice(counts, func(i, j int) bool {
+		if counts[i].Count != counts[j].Count {
+			return counts[i].Count > counts[j].Count
+		}
+		return counts[i].Char < counts[j].Char
+	})
+	return counts
+}
+
+// mostFrequentChar returns the most common character in freq and its count.
+// Ties go to the lowest rune so the result is stable. Every character is
+// considered, so whitespace can win for inputs with many spaces.
+func mostFrequentChar(freq map[string]int) (string, int) {
+	best, bestCount := "", 0
+	for char, count := range freq {
+		// Keys are single runes, so byte order matches rune order
+		if count > bestCount || (count == bestCount && char < best) {
+			best, bestCount = char, count
+		}
+	}
+	return best, bestCount
+}
+
+// timestampLayout is RFC 3339 with a fixed nine-digit fraction. Unlike
+// time.RFC3339Nano it keeps trailing zeros, so timestamps sort as strings
+// in creation order even within the same second.
+const timestampLayout = "2006-01-02T15:04:05.000000000Z07:00"
+
+func getCurrentTime() string {
+	return time.Now().UTC().Format(timestampLayout)
+}
+
+// levenshtein returns the edit distance between a and b, counted in runes.
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+
+	return prev[len(rb)]
+}
+
+// anagramSignature lowercases s, drops whitespace and sorts the remaining
+// runes, so two strings are anagrams exactly when their signatures match.
+func anagramSignature(s string) string {
+	runes := make([]rune, 0, len(s))
+	for _, r := range strings.ToLower(s) {
+		if !unicode.IsSpace(r) {
+			runes = append(runes, r)
+		}
+	}
+	sort.Slice(runes, func(i, j int) bool { return runes[i] < runes[j] })
+	return string(runes)
+}
+
+// ===== STORAGE =====
+
+var (
+	ErrNotFound      = errors.New("not found")
+	ErrAlreadyExists = errors.New("already exists")
+)
+
+// Store is the storage backend used by StringHandler. Implementations must be
+// safe for concurrent use and report missing or duplicate values with
+// ErrNotFound and ErrAlreadyExists so handlers can map them to status codes.
+type Store interface {
+	Create(analysis *StringAnalysis) error
+	CreateCaseInsensitive(analysis *StringAnalysis) (string, error)
+	Get(value string) (*StringAnalysis, error)
+	// GetMany looks up every value in one pass, returning the live entries
+	// found keyed by value. Missing values are simply absent.
+	GetMany(values []string) (map[string]*StringAnalysis, error)
+	GetByID(id string) (*StringAnalysis, error)
+	GetAll(filters map[string]interface{}) []*StringAnalysis
+	// Each calls fn for every entry matching filters, stopping at the first
+	// error fn returns.
+	Each(filters map[string]interface{}, fn func(*StringAnalysis) error) error
+	// GetIncludingDeleted is Get, but also returns a tombstone.
+	GetIncludingDeleted(value string) (*StringAnalysis, error)
+	Update(value string, analysis *StringAnalysis) error
+	// Delete soft-deletes value, leaving a tombstone until Purge.
+	Delete(value string) error
+	// Remove hard-deletes value, whether live or a tombstone, leaving no
+	// trace of it.
+	Remove(value string) error
+	// Purge hard-deletes every tombstone and returns how many it removed.
+	Purge() int
+	Clear()
+	// Len counts live entries, not tombstones.
+	Len() int
+}
+
+var (
+	_ Store = (*MemoryStore)(nil)
+	_ Store = (*FileStore)(nil)
+	_ Store = (*SQLiteStore)(nil)
+)
+
+type MemoryStore struct {
+	mu      sync.RWMutex
+	strings map[string]*StringAnalysis
+	hashes  map[string]string
+	// folded indexes stored values by their lowercased form for
+	// case-insensitive duplicate detection.
+	folded map[string]map[string]bool
+
+	// maxEntries caps the number of entries, tombstones included; zero
+	// means unbounded.
+	maxEntries int
+	// recency orders live values from most to least recently accessed, and
+	// elements indexes it by value. Reads refresh recency under the read
+	// lock, so recencyMu guards both.
+	recencyMu sync.Mutex
+	recency   *list.List
+	elements  map[string]*list.Element
+}
+
+// NewMemoryStore returns an empty store. When maxEntries is positive,
+// creating an entry in a full store first evicts the least recently accessed
+// one.
+func NewMemoryStore(maxEntries int) *MemoryStore {
+	return &MemoryStore{
+		strings:    make(map[string]*StringAnalysis),
+		hashes:     make(map[string]string),
+		folded:     make(map[string]map[string]bool),
+		maxEntries: maxEntries,
+		recency:    list.New(),
+		elements:   make(map[string]*list.Element),
+	}
+}
+
+func (s *MemoryStore) Create(analysis *StringAnalysis) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.live(analysis.Value); exists {
+		return ErrAlreadyExists
+	}
+
+	s.replaceTombstone(analysis.Value)
+	s.evictForInsert()
+	s.insert(analysis)
+
+	return nil
+}
+
+// CreateCaseInsensitive stores analysis unless a value differing only in case
+// is already present, in which case that existing value is returned along
+// with ErrAlreadyExists.
+func (s *MemoryStore) CreateCaseInsensitive(analysis *StringAnalysis) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.live(analysis.Value); exists {
+		return analysis.Value, ErrAlreadyExists
+	}
+
+	var existing []string
+	for value := range s.folded[strings.ToLower(analysis.Value)] {
+		if _, ok := s.live(value); ok {
+			existing = append(existing, value)
+		}
+	}
+	if len(existing) > 0 {
+		sort.Strings(existing)
+		return existing[0], ErrAlreadyExists
+	}
+
+	s.replaceTombstone(analysis.Value)
+	s.evictForInsert()
+	s.insert(analysis)
+
+	return "", nil
+}
+
+// live returns the entry stored under value unless it is missing or a
+// tombstone. Callers must hold the lock.
+func (s *MemoryStore) live(value string) (*StringAnalysis, bool) {
+	analysis, exists := s.strings[value]
+	if !exists || analysis.Deleted() {
+		return nil, false
+	}
+	return analysis, true
+}
+
+// replaceTombstone drops a tombstone stored under value so a new entry can
+// take its place. Callers must hold the write lock.
+func (s *MemoryStore) replaceTombstone(value string) {
+	if analysis, exists := s.strings[value]; exists && analysis.Deleted() {
+		s.remove(analysis)
+	}
+}
+
+// evictForInsert makes room for one more entry. Tombstones count toward the
+// cap, so soft deletes cannot grow the store without bound; the oldest
+// tombstones go first, then the least recently accessed live entries.
+// Callers must hold the write lock.
+func (s *MemoryStore) evictForInsert() {
+	if s.maxEntries <= 0 {
+		return
+	}
+
+	for len(s.strings) >= s.maxEntries {
+		if tombstone := s.oldestTombstone(); tombstone != nil {
+			s.remove(tombstone)
+			slog.Info("store full, evicted oldest deleted string", "value", tombstone.Value, "max_entries", s.maxEntries)
+			continue
+		}
+
+		value := s.recency.Back().Value.(string)
+		s.remove(s.strings[value])
+		slog.Info("store full, evicted least recently used string", "value", value, "max_entries", s.maxEntries)
+	}
+}
+
+// oldestTombstone returns the earliest soft-deleted entry, or nil when there
+// are none. Tombstones are not in the recency list, so this scans the store;
+// it only runs once the store is full. Callers must hold the lock.
+func (s *MemoryStore) oldestTombstone() *StringAnalysis {
+	if len(s.strings) == s.recency.Len() {
+		return nil
+	}
+
+	var oldest *StringAnalysis
+	for _, analysis := range s.strings {
+		if analysis.Deleted() && (oldest == nil || analysis.DeletedAt < oldest.DeletedAt) {
+			oldest = analysis
+		}
+	}
+	return oldest
+}
+
+// touch marks value as the most recently accessed entry. It only needs the
+// read lock.
+func (s *MemoryStore) touch(value string) {
+	s.recencyMu.Lock()
+	defer s.recencyMu.Unlock()
+
+	if elem, ok := s.elements[value]; ok {
+		s.recency.MoveToFront(elem)
+	}
+}
+
+// forget drops value from the recency list. Callers must hold the write lock.
+func (s *MemoryStore) forget(value string) {
+	s.recencyMu.Lock()
+	defer s.recencyMu.Unlock()
+
+	if elem, ok := s.elements[value]; ok {
+		s.recency.Remove(elem)
+		delete(s.elements, value)
+	}
+}
+
+// insert adds analysis to every index. Callers must hold the write lock.
+func (s *MemoryStore) insert(analysis *StringAnalysis) {
+	s.strings[analysis.Value] = analysis
+	s.hashes[analysis.ID] = analysis.Value
+
+	if !analysis.Deleted() {
+		s.recencyMu.Lock()
+		s.elements[analysis.Value] = s.recency.PushFront(analysis.Value)
+		s.recencyMu.Unlock()
+	}
+
+	key := strings.ToLower(analysis.Value)
+	if s.folded[key] == nil {
+		s.folded[key] = make(map[string]bool)
+	}
+	s.folded[key][analysis.Value] = true
+}
+
+// remove drops analysis from every index. Callers must hold the write lock.
+func (s *MemoryStore) remove(analysis *StringAnalysis) {
+	delete(s.strings, analysis.Value)
+	delete(s.hashes, analysis.ID)
+	s.forget(analysis.Value)
+
+	key := strings.ToLower(analysis.Value)
+	delete(s.folded[key], analysis.Value)
+	if len(s.folded[key]) == 0 {
+		delete(s.folded, key)
+	}
+}
+
+func (s *MemoryStore) Get(value string) (*StringAnalysis, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	analysis, exists := s.live(value)
+	if !exists {
+		return nil, ErrNotFound
+	}
+	s.touch(value)
+
+	return analysis, nil
+}
+
+// GetMany takes the read lock once for the whole batch.
+func (s *MemoryStore) GetMany(values []string) (map[string]*StringAnalysis, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	found := make(map[string]*StringAnalysis)
+	for _, value := range values {
+		if analysis, exists := s.live(value); exists {
+			found[value] = analysis
+			s.touch(value)
+		}
+	}
+
+	return found, nil
+}
+
+func (s *MemoryStore) GetIncludingDeleted(value string) (*StringAnalysis, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	analysis, exists := s.strings[value]
+	if !exists {
+		return nil, ErrNotFound
+	}
+
+	return analysis, nil
+}
+
+// GetByID resolves a live entry by its hash ID.
+func (s *MemoryStore) GetByID(id string) (*StringAnalysis, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	value, exists := s.hashes[id]
+	if !exists {
+		return nil, ErrNotFound
+	}
+
+	analysis, exists := s.live(value)
+	if !exists {
+		return nil, ErrNotFound
+	}
+	s.touch(value)
+
+	return analysis, nil
+}
+
+func (s *MemoryStore) GetAll(filters map[string]interface{}) []*StringAnalysis {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var results []*StringAnalysis
+
+	for _, analysis := range s.strings {
+		if matchesFilters(analysis, filters) {
+			results = append(results, analysis)
+		}
+	}
+
+	return results
+}
+
+// Each snapshots the matching pointers under the read lock and releases it
+// before calling fn, so a slow consumer never blocks writers.
+func (s *MemoryStore) Each(filters map[string]interface{}, fn func(*StringAnalysis) error) error {
+	for _, analysis := range s.GetAll(filters) {
+		if err := fn(analysis); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Delete replaces the entry with a tombstone. The stored pointer may be held
+// by readers, so the tombstone is a copy rather than an in-place edit.
+func (s *MemoryStore) Delete(value string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	analysis, exists := s.live(value)
+	if !exists {
+		return ErrNotFound
+	}
+
+	tombstone := *analysis
+	tombstone.DeletedAt = getCurrentTime()
+	s.strings[value] = &tombstone
+	s.forget(value)
+
+	return nil
+}
+
+func (s *MemoryStore) Remove(value string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	analysis, exists := s.strings[value]
+	if !exists {
+		return ErrNotFound
+	}
+	s.remove(analysis)
+
+	return nil
+}
+
+// Full reports whether creating another entry would evict one.
+func (s *MemoryStore) Full() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.maxEntries > 0 && len(s.strings) >= s.maxEntries
+}
+
+// Purge hard-deletes every tombstone.
+func (s *MemoryStore) Purge() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	purged := 0
+	for _, analysis := range s.strings {
+		if analysis.Deleted() {
+			s.remove(analysis)
+			purged++
+		}
+	}
+
+	return purged
+}
+
+// Len returns the number of live entries.
+func (s *MemoryStore) Len() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	count := 0
+	for _, analysis := range s.strings {
+		if !analysis.Deleted() {
+			count++
+		}
+	}
+
+	return count
+}
+
+// Clear removes every stored entry.
+func (s *MemoryStore) Clear() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.strings = make(map[string]*StringAnalysis)
+	s.hashes = make(map[string]string)
+	s.folded = make(map[string]map[string]bool)
+
+	s.recencyMu.Lock()
+	s.recency.Init()
+	s.elements = make(map[string]*list.Element)
+	s.recencyMu.Unlock()
+}
+
+// Update replaces the entry stored under value with analysis, which may carry
+// a different value. The original creation time is preserved; UpdatedAt is
+// taken from analysis.
+func (s *MemoryStore) Update(value string, analysis *StringAnalysis) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	existing, exists := s.live(value)
+	if !exists {
+		return ErrNotFound
+	}
+
+	if analysis.Value != value {
+		if _, taken := s.live(analysis.Value); taken {
+			return ErrAlreadyExists
+		}
+		s.replaceTombstone(analysis.Value)
+	}
+
+	analysis.CreatedAt = existing.CreatedAt
+
+	s.remove(existing)
+	s.insert(analysis)
+
+	return nil
+}
+
+func matchesFilters(analysis *StringAnalysis, filters map[string]interface{}) bool {
+	if includeDeleted, _ := filters["include_deleted"].(bool); analysis.Deleted() && !includeDeleted {
+		return false
+	}
+
+	if val, ok := filters["is_palindrome"].(bool); ok {
+		if analysis.Properties.IsPalindrome != val {
+			return false
+		}
+	}
+
+	if val, ok := filters["is_pangram"].(bool); ok {
+		if analysis.Properties.IsPangram != val {
+			return false
+		}
+	}
+
+	if val, ok := filters["min_length"].(int); ok {
+		if analysis.Properties.Length < val {
+			return false
+		}
+	}
+
+	if val, ok := filters["max_length"].(int); ok {
+		if analysis.Properties.Length > val {
+			return false
+		}
+	}
+
+	if val, ok := filters["word_count"].(int); ok {
+		if analysis.Properties.WordCount != val {
+			return false
+		}
+	}
+
+	if val, ok := filters["min_word_count"].(int); ok {
+		if analysis.Properties.WordCount < val {
+			return false
+		}
+	}
+
+	if val, ok := filters["max_word_count"].(int); ok {
+		if analysis.Properties.WordCount > val {
+			return false
+		}
+	}
+
+	// contains_character is a single string from the natural language parser
+	// and a slice from the query string; every character must be present.
+	switch val := filters["contains_character"].(type) {
+	case string:
+		if !containsChar(analysis.Value, val) {
+			return false
+		}
+	case []string:
+		for _, char := range val {
+			if !containsChar(analysis.Value, char) {
+				return false
+			}
+		}
+	}
+
+	if val, ok := filters["excludes_character"].([]string); ok {
+		for _, char := range val {
+			if strings.Contains(analysis.Value, char) {
+				return false
+			}
+		}
+	}
+
+	if val, ok := filters["contains_substring"].(string); ok {
+		caseInsensitive, _ := filters["case_insensitive"].(bool)
+		if !containsSubstring(analysis.Value, val, caseInsensitive) {
+			return false
+		}
+	}
+
+	if val, ok := filters["starts_with"].(string); ok {
+		if !strings.HasPrefix(analysis.Value, val) {
+			return false
+		}
+	}
+
+	if val, ok := filters["ends_with"].(string); ok {
+		if !strings.HasSuffix(analysis.Value, val) {
+			return false
+		}
+	}
+
+	if val, ok := filters["min_unique_chars"].(int); ok {
+		if analysis.Properties.UniqueCharacters < val {
+			return false
+		}
+	}
+
+	if val, ok := filters["max_unique_chars"].(int); ok {
+		if analysis.Properties.UniqueCharacters > val {
+			return false
+		}
+	}
+
+	if val, ok := filters["min_entropy"].(float64); ok {
+		if analysis.Properties.Entropy < val {
+			return false
+		}
+	}
+
+	if val, ok := filters["max_entropy"].(float64); ok {
+		if analysis.Properties.Entropy > val {
+			return false
+		}
+	}
+
+	if re, ok := filters["pattern"].(*regexp.Regexp); ok {
+		if !re.MatchString(analysis.Value) {
+			return false
+		}
+	}
+
+	_, hasAfter := filters["created_after"]
+	_, hasBefore := filters["created_before"]
+	if hasAfter || hasBefore {
+		createdAt, err := time.Parse(time.RFC3339, analysis.CreatedAt)
+		if err != nil {
+			return false
+		}
+		if after, ok := filters["created_after"].(time.Time); ok && !createdAt.After(after) {
+			return false
+		}
+		if before, ok := filters["created_before"].(time.Time); ok && !createdAt.Before(before) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func containsSubstring(s, substr string, caseInsensitive bool) bool {
+	if caseInsensitive {
+		return strings.Contains(strings.ToLower(s), strings.ToLower(substr))
+	}
+	return strings.Contains(s, substr)
+}
+
+func containsChar(s, char string) bool {
+	if len(char) == 0 {
+		return true
+	}
+	return strings.Contains(s, char)
+}
+
+// ===== FILE STORAGE =====
+
+// FileStore is a MemoryStore that writes its contents to a JSON file after
+// every mutation and reloads them on startup.
+type FileStore struct {
+	*MemoryStore
+	path string
+	// saveMu serializes writes so snapshots reach the disk in order.
+	saveMu sync.Mutex
+}
+
+// NewFileStore loads entries from path. A missing file starts an empty store;
+// a corrupt file is moved aside to path+".corrupt" and also starts empty.
+func NewFileStore(path string, maxEntries int) (*FileStore, error) {
+	fs := &FileStore{MemoryStore: NewMemoryStore(maxEntries), path: path}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return fs, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []*StringAnalysis
+	if err := json.Unmarshal(data, &entries); err != nil {
+		slog.Warn("store file is corrupt, moving it aside", "path", path, "moved_to", path+".corrupt", "error", err)
+		if err := os.Rename(path, path+".corrupt"); err != nil {
+			return nil, err
+		}
+		return fs, nil
+	}
+
+	for _, analysis := range entries {
+		if analysis == nil {
+			continue
+		}
+		// Files written before updated_at existed lack it
+		if analysis.UpdatedAt == "" {
+			analysis.UpdatedAt = analysis.CreatedAt
+		}
+		// ...and before palindrome_mode existed, the only mode was the default
+		if analysis.Properties.PalindromeMode == "" {
+			analysis.Properties.PalindromeMode = defaultPalindromeMode
+		}
+		backfillHash(analysis)
+		fs.insert(analysis)
+	}
+
+	return fs, nil
+}
+
+func (fs *FileStore) Create(analysis *StringAnalysis) error {
+	if err := fs.MemoryStore.Create(analysis); err != nil {
+		return err
+	}
+	fs.save()
+	return nil
+}
+
+func (fs *FileStore) CreateCaseInsensitive(analysis *StringAnalysis) (string, error) {
+	existing, err := fs.MemoryStore.CreateCaseInsensitive(analysis)
+	if err != nil {
+		return existing, err
+	}
+	fs.save()
+	return "", nil
+}
+
+func (fs *FileStore) Update(value string, analysis *StringAnalysis) error {
+	if err := fs.MemoryStore.Update(value, analysis); err != nil {
+		return err
+	}
+	fs.save()
+	return nil
+}
+
+func (fs *FileStore) Delete(value string) error {
+	if err := fs.MemoryStore.Delete(value); err != nil {
+		return err
+	}
+	fs.save()
+	return nil
+}
+
+func (fs *FileStore) Remove(value string) error {
+	if err := fs.MemoryStore.Remove(value); err != nil {
+		return err
+	}
+	fs.save()
+	return nil
+}
+
+func (fs *FileStore) Purge() int {
+	purged := fs.MemoryStore.Purge()
+	if purged > 0 {
+		fs.save()
+	}
+	return purged
+}
+
+func (fs *FileStore) Clear() {
+	fs.MemoryStore.Clear()
+	fs.save()
+}
+
+// Probe checks the store file can be read and its directory written to,
+// without touching the entries, so /ready never rewrites the file or leaves
+// a sentinel behind after a crash. A missing file is fine: it is created on
+// the first save.
+func (fs *FileStore) Probe() (map[string]string, error) {
+	checks := make(map[string]string)
+
+	f, err := os.Open(fs.path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		checks["read"] = err.Error()
+		return checks, fmt.Errorf("read: %w", err)
+	}
+	if f != nil {
+		f.Close()
+	}
+	checks["read"] = "ok"
+
+	tmp, err := os.CreateTemp(filepath.Dir(fs.path), filepath.Base(fs.path)+".probe-*")
+	if err == nil {
+		_, err = tmp.Write([]byte("{}"))
+		tmp.Close()
+		os.Remove(tmp.Name())
+	}
+	if err != nil {
+		checks["write"] = err.Error()
+		return checks, fmt.Errorf("write: %w", err)
+	}
+	checks["write"] = "ok"
+
+	return checks, nil
+}
+
+// save writes the current contents to a temporary file and renames it over
+// the store file so a crash never leaves a half-written file behind. The
+// in-memory mutation has already succeeded, so failures are only logged.
+func (fs *FileStore) save() {
+	fs.saveMu.Lock()
+	defer fs.saveMu.Unlock()
+
+	// Tombstones are saved too, so soft deletes survive a restart
+	entries := fs.MemoryStore.GetAll(map[string]interface{}{"include_deleted": true})
+	sortResults(entries, "created_at", false)
+
+	data, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		slog.Error("failed to encode store", "error", err)
+		return
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(fs.path), filepath.Base(fs.path)+".tmp-*")
+	if err != nil {
+		slog.Error("failed to save store", "path", fs.path, "error", err)
+		return
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		slog.Error("failed to save store", "path", fs.path, "error", err)
+		return
+	}
+	if err := tmp.Close(); err != nil {
+		slog.Error("failed to save store", "path", fs.path, "error", err)
+		return
+	}
+
+	if err := os.Rename(tmp.Name(), fs.path); err != nil {
+		slog.Error("failed to save store", "path", fs.path, "error", err)
+	}
+}
+
+// ===== SQLITE STORAGE =====
+
+const sqliteSchema = `
+CREATE TABLE IF NOT EXISTS strings (
+	value         TEXT PRIMARY KEY,
+	value_folded  TEXT NOT NULL,
+	id            TEXT NOT NULL,
+	length        INTEGER NOT NULL,
+	is_palindrome INTEGER NOT NULL,
+	word_count    INTEGER NOT NULL,
+	properties    TEXT NOT NULL,
+	created_at    TEXT NOT NULL,
+	updated_at    TEXT NOT NULL DEFAULT '',
+	deleted_at    TEXT NOT NULL DEFAULT ''
+);
+CREATE INDEX IF NOT EXISTS strings_value_folded ON strings (value_folded);
+CREATE INDEX IF NOT EXISTS strings_id ON strings (id);
+`
+
+// SQLiteStore keeps entries in a SQLite database. The filterable properties
+// have their own columns; the full Properties struct is stored as JSON.
+type SQLiteStore struct {
+	db *sql.DB
+}
+
+func NewSQLiteStore(path string) (*SQLiteStore, error) {
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, err
+	}
+
+	// SQLite allows a single writer; one connection avoids "database is
+	// locked" errors under concurrent requests.
+	db.SetMaxOpenConns(1)
+
+	if _, err := db.Exec(sqliteSchema); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	if err := migrateSQLite(db); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return &SQLiteStore{db: db}, nil
+}
+
+// sqliteAddedColumns are columns added after the first schema, in order.
+// migrateSQLite adds any that an existing database lacks.
+var sqliteAddedColumns = []struct{ name, definition string }{
+	// An empty deleted_at marks a live entry
+	{"deleted_at", `TEXT NOT NULL DEFAULT ''`},
+	// An empty updated_at falls back to created_at when read
+	{"updated_at", `TEXT NOT NULL DEFAULT ''`},
+}
+
+func migrateSQLite(db *sql.DB) error {
+	for _, col := range sqliteAddedColumns {
+		var count int
+		err := db.QueryRow(`SELECT COUNT(*) FROM pragma_table_info('strings') WHERE name = ?`, col.name).Scan(&count)
+		if err != nil {
+			return err
+		}
+		if count > 0 {
+			continue
+		}
+
+		if _, err := db.Exec(`ALTER TABLE strings ADD COLUMN ` + col.name + ` ` + col.definition); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *SQLiteStore) Close() error {
+	return s.db.Close()
+}
+
+func (s *SQLiteStore) Create(analysis *StringAnalysis) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := insertSQLite(tx, analysis); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Probe inserts a sentinel and reads it back inside a transaction that is
+// always rolled back, so /ready exercises the database without other
+// requests ever seeing the sentinel.
+func (s *SQLiteStore) Probe() (map[string]string, error) {
+	checks := make(map[string]string)
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		checks["begin"] = err.Error()
+		return checks, fmt.Errorf("begin: %w", err)
+	}
+	defer tx.Rollback()
+
+	value := readyProbePrefix + newRequestID()
+	if err := insertSQLite(tx, NewStringAnalysis(value, AnalysisOptions{})); err != nil {
+		checks["create"] = err.Error()
+		return checks, fmt.Errorf("create: %w", err)
+	}
+	checks["create"] = "ok"
+
+	analysis, err := scanSQLite(tx.QueryRow(`SELECT `+sqliteColumns+` FROM strings WHERE value = ?`, value))
+	if err == nil && analysis.Value != value {
+		err = fmt.Errorf("got %q back", analysis.Value)
+	}
+	if err != nil {
+		checks["get"] = err.Error()
+		return checks, fmt.Errorf("get: %w", err)
+	}
+	checks["get"] = "ok"
+
+	return checks, nil
+}
+
+func (s *SQLiteStore) CreateCaseInsensitive(analysis *StringAnalysis) (string, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return "", err
+	}
+	defer tx.Rollback()
+
+	var existing string
+	err = tx.QueryRow(
+		`SELECT value FROM strings WHERE value_folded = ? AND deleted_at = '' ORDER BY value LIMIT 1`,
+		strings.ToLower(analysis.Value),
+	).Scan(&existing)
+	if err == nil {
+		return existing, ErrAlreadyExists
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return "", err
+	}
+
+	if err := insertSQLite(tx, analysis); err != nil {
+		return analysis.Value, err
+	}
+
+	return "", tx.Commit()
+}
+
+func (s *SQLiteStore) Get(value string) (*StringAnalysis, error) {
+	row := s.db.QueryRow(`SELECT `+sqliteColumns+` FROM strings WHERE value = ? AND deleted_at = ''`, value)
+
+	analysis, err := scanSQLite(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+
+	return analysis, err
+}
+
+// GetMany fetches the batch in a single query, passing the values as one JSON
+// array so large batches stay under SQLite's bound-parameter limit.
+func (s *SQLiteStore) GetMany(values []string) (map[string]*StringAnalysis, error) {
+	list, err := json.Marshal(values)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := s.db.Query(`SELECT `+sqliteColumns+` FROM strings
+		WHERE deleted_at = '' AND value IN (SELECT value FROM json_each(?))`, string(list))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	found := make(map[string]*StringAnalysis)
+	for rows.Next() {
+		analysis, err := scanSQLite(rows)
+		if err != nil {
+			return nil, err
+		}
+		found[analysis.Value] = analysis
+	}
+
+	return found, rows.Err()
+}
+
+func (s *SQLiteStore) GetIncludingDeleted(value string) (*StringAnalysis, error) {
+	row := s.db.QueryRow(`SELECT `+sqliteColumns+` FROM strings WHERE value = ?`, value)
+
+	analysis, err := scanSQLite(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+
+	return analysis, err
+}
+
+func (s *SQLiteStore) GetByID(id string) (*StringAnalysis, error) {
+	row := s.db.QueryRow(`SELECT `+sqliteColumns+` FROM strings WHERE id = ? AND deleted_at = ''`, id)
+
+	analysis, err := scanSQLite(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+
+	return analysis, err
+}
+
+// GetAll pushes the length, palindrome and word count filters down into SQL
+// and applies the remaining filters in memory.
+func (s *SQLiteStore) GetAll(filters map[string]interface{}) []*StringAnalysis {
+	var results []*StringAnalysis
+	err := s.Each(filters, func(analysis *StringAnalysis) error {
+		results = append(results, analysis)
+		return nil
+	})
+	if err != nil {
+		slog.Error("failed to query strings", "error", err)
+	}
+
+	return results
+}
+
+// sqliteEachBatch is how many rows Each reads before releasing the
+// connection to call fn on them.
+const sqliteEachBatch = 500
+
+// Each streams rows from the database in batches of sqliteEachBatch, walking
+// the table by rowid. Each batch is read and its rows closed before fn runs,
+// so a slow consumer (e.g. an NDJSON client) does not hold the store's only
+// connection. The indexed columns are filtered in SQL; everything else goes
+// through matchesFilters.
+func (s *SQLiteStore) Each(filters map[string]interface{}, fn func(*StringAnalysis) error) error {
+	var where []string
+	var args []interface{}
+
+	if includeDeleted, _ := filters["include_deleted"].(bool); !includeDeleted {
+		where = append(where, "deleted_at = ''")
+	}
+	if val, ok := filters["is_palindrome"].(bool); ok {
+		where = append(where, "is_palindrome = ?")
+		args = append(args, val)
+	}
+	if val, ok := filters["min_length"].(int); ok {
+		where = append(where, "length >= ?")
+		args = append(args, val)
+	}
+	if val, ok := filters["max_length"].(int); ok {
+		where = append(where, "length <= ?")
+		args = append(args, val)
+	}
+	if val, ok := filters["word_count"].(int); ok {
+		where = append(where, "word_count = ?")
+		args = append(args, val)
+	}
+	if val, ok := filters["min_word_count"].(int); ok {
+		where = append(where, "word_count >= ?")
+		args = append(args, val)
+	}
+	if val, ok := filters["max_word_count"].(int); ok {
+		where = append(where, "word_count <= ?")
+		args = append(args, val)
+	}
+
+	where = append(where, "rowid > ?")
+	query := `SELECT rowid, ` + sqliteColumns + ` FROM strings WHERE ` +
+		strings.Join(where, " AND ") + ` ORDER BY rowid LIMIT ?`
+
+	var lastRowID int64
+	for {
+		batch, read, err := s.eachBatch(query, append(args, lastRowID, sqliteEachBatch), &lastRowID)
+		if err != nil {
+			return err
+		}
+
+		for _, analysis := range batch {
+			if !matchesFilters(analysis, filters) {
+				continue
+			}
+			if err := fn(analysis); err != nil {
+				return err
+			}
+		}
+
+		if read < sqliteEachBatch {
+			return nil
+		}
+	}
+}
+
+// eachBatch runs one page of Each's query and closes its rows before
+// returning. It advances lastRowID past the rows read and reports how many
+// there were; rows that fail to decode are logged and left out of the batch.
+func (s *SQLiteStore) eachBatch(query string, args []interface{}, lastRowID *int64) ([]*StringAnalysis, int, error) {
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	start := *lastRowID
+	var batch []*StringAnalysis
+	read := 0
+	for rows.Next() {
+		read++
+		var rowid int64
+		analysis, err := scanSQLite(rowidScanner{rows, &rowid})
+		*lastRowID = max(*lastRowID, rowid)
+		if err != nil {
+			slog.Error("failed to read string", "error", err)
+			continue
+		}
+		batch = append(batch, analysis)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+	// A full page of unreadable rows would otherwise be fetched forever
+	if read == sqliteEachBatch && *lastRowID == start {
+		return nil, 0, errors.New("no readable rows in batch")
+	}
+
+	return batch, read, nil
+}
+
+// rowidScanner reads a leading rowid column into rowid before handing the
+// rest of the row to the wrapped scanner's destinations.
+type rowidScanner struct {
+	sqlScanner
+	rowid *int64
+}
+
+func (r rowidScanner) Scan(dest ...interface{}) error {
+	return r.sqlScanner.Scan(append([]interface{}{r.rowid}, dest...)...)
+}
+
+func (s *SQLiteStore) Update(value string, analysis *StringAnalysis) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var createdAt string
+	err = tx.QueryRow(`SELECT created_at FROM strings WHERE value = ? AND deleted_at = ''`, value).Scan(&createdAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(`DELETE FROM strings WHERE value = ?`, value); err != nil {
+		return err
+	}
+
+	analysis.CreatedAt = createdAt
+	if err := insertSQLite(tx, analysis); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Delete marks the row as a tombstone by setting deleted_at.
+func (s *SQLiteStore) Delete(value string) error {
+	res, err := s.db.Exec(`UPDATE strings SET deleted_at = ? WHERE value = ? AND deleted_at = ''`, getCurrentTime(), value)
+	if err != nil {
+		return err
+	}
+
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+func (s *SQLiteStore) Remove(value string) error {
+	res, err := s.db.Exec(`DELETE FROM strings WHERE value = ?`, value)
+	if err != nil {
+		return err
+	}
+
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+func (s *SQLiteStore) Purge() int {
+	res, err := s.db.Exec(`DELETE FROM strings WHERE deleted_at != ''`)
+	if err != nil {
+		slog.Error("failed to purge strings", "error", err)
+		return 0
+	}
+
+	n, _ := res.RowsAffected()
+	return int(n)
+}
+
+func (s *SQLiteStore) Len() int {
+	var count int
+	if err := s.db.QueryRow(`SELECT COUNT(*) FROM strings WHERE deleted_at = ''`).Scan(&count); err != nil {
+		slog.Error("failed to count strings", "error", err)
+	}
+	return count
+}
+
+func (s *SQLiteStore) Clear() {
+	if _, err := s.db.Exec(`DELETE FROM strings`); err != nil {
+		slog.Error("failed to clear strings", "error", err)
+	}
+}
+
+// sqlExecer is satisfied by both *sql.DB and *sql.Tx.
+type sqlExecer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+}
+
+// insertSQLite adds analysis, returning ErrAlreadyExists if the value is
+// already stored. A tombstone under the same value is replaced.
+func insertSQLite(db sqlExecer, analysis *StringAnalysis) error {
+	props, err := json.Marshal(analysis.Properties)
+	if err != nil {
+		return err
+	}
+
+	if _, err := db.Exec(`DELETE FROM strings WHERE value = ? AND deleted_at != ''`, analysis.Value); err != nil {
+		return err
+	}
+
+	res, err := db.Exec(
+		`INSERT OR IGNORE INTO strings
+			(value, value_folded, id, length, is_palindrome, word_count, properties, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		analysis.Value,
+		strings.ToLower(analysis.Value),
+		analysis.ID,
+		analysis.Properties.Length,
+		analysis.Properties.IsPalindrome,
+		analysis.Properties.WordCount,
+		string(props),
+		analysis.CreatedAt,
+		analysis.UpdatedAt,
+	)
+	if err != nil {
+		return err
+	}
+
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrAlreadyExists
+	}
+
+	return nil
+}
+
+// sqlScanner is satisfied by both *sql.Row and *sql.Rows.
+type sqlScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// sqliteColumns is the column list scanSQLite expects.
+const sqliteColumns = `value, id, properties, created_at, updated_at, deleted_at`
+
+// scanSQLite reads a row selected as sqliteColumns.
+func scanSQLite(row sqlScanner) (*StringAnalysis, error) {
+	var analysis StringAnalysis
+	var props string
+
+	if err := row.Scan(&analysis.Value, &analysis.ID, &props, &analysis.CreatedAt, &analysis.UpdatedAt, &analysis.DeletedAt); err != nil {
+		return nil, err
+	}
+	if analysis.UpdatedAt == "" {
+		analysis.UpdatedAt = analysis.CreatedAt
+	}
+
+	if err := json.Unmarshal([]byte(props), &analysis.Properties); err != nil {
+		return nil, err
+	}
+	if analysis.Properties.PalindromeMode == "" {
+		analysis.Properties.PalindromeMode = defaultPalindromeMode
+	}
+	backfillHash(&analysis)
+
+	return &analysis, nil
+}
+
+// ===== HANDLERS =====
+
+type StringHandler struct {
+	store   Store
+	metrics *Metrics
+	config  Config
+}
+
+func NewStringHandler(store Store, metrics *Metrics, config Config) *StringHandler {
+	return &StringHandler{store: store, metrics: metrics, config: config}
+}
+
+// maxBodyBytes bounds a request body carrying a single value. JSON can spend
+// up to 12 bytes on one rune (a \u-escaped surrogate pair), plus some slack
+// for the surrounding object.
+func (h *StringHandler) maxBodyBytes() int64 {
+	return int64(h.config.MaxStringLength)*12 + 1024
+}
+
+// checkLength rejects values longer than the configured maximum.
+func (h *StringHandler) checkLength(w http.ResponseWriter, value string) bool {
+	if utf8.RuneCountInString(value) > h.config.MaxStringLength {
+		respondError(w, http.StatusRequestEntityTooLarge,
+			fmt.Sprintf("Value exceeds maximum length of %d characters", h.config.MaxStringLength))
+		return false
+	}
+	return true
+}
+
+// AnalyzeString computes the analysis of the posted value, or of ?value= on
+// GET, without storing it, so it never conflicts and leaves the store
+// untouched. With ?fields= only the listed properties are computed.
+func (h *StringHandler) AnalyzeString(w http.ResponseWriter, r *http.Request) {
+	fields, err := parseFields(r.URL.Query())
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	palindromeMode, err := parsePalindromeMode(r.URL.Query())
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	// GET takes the value from the (already decoded) query string, for
+	// clients that cannot send a body
+	value := r.URL.Query().Get("value")
+
+	if r.Method == http.MethodPost {
+		r.Body = http.MaxBytesReader(w, r.Body, h.maxBodyBytes())
+
+		var req struct {
+			Value string `json:"value"`
+		}
+
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			respondDecodeError(w, err)
+			return
+		}
+		value = req.Value
+	}
+
+	if value == "" {
+		respondError(w, http.StatusBadRequest, "Missing 'value' field")
+		return
+	}
+
+	if !h.checkLength(w, value) {
+		return
+	}
+
+	analysis := NewStringAnalysis(value, AnalysisOptions{
+		Fields:         fields,
+		PalindromeMode: palindromeMode,
+		HashAlgorithm:  h.config.HashAlgorithm,
+	})
+	respondJSON(w, http.StatusOK, selectProperties(analysis, fields))
+}
+
+func (h *StringHandler) CreateString(w http.ResponseWriter, r *http.Request) {
+	// Stored entries are always fully analyzed so every filter keeps working;
+	// ?fields= only trims the response
+	fields, err := parseFields(r.URL.Query())
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	palindromeMode, err := parsePalindromeMode(r.URL.Query())
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, h.maxBodyBytes())
+
+	var req struct {
+		Value string `json:"value"`
+		// Encoding "base64" lets clients send values that are awkward to
+		// put in JSON; the decoded string is what gets stored
+		Encoding string `json:"encoding"`
+	}
+
+	// The raw body is kept because encoding/json silently replaces invalid
+	// UTF-8 and unpaired surrogate escapes with U+FFFD while decoding
+	raw, err := io.ReadAll(r.Body)
+	if err != nil {
+		respondDecodeError(w, err)
+		return
+	}
+
+	if err := json.NewDecoder(bytes.NewReader(raw)).Decode(&req); err != nil {
+		respondDecodeError(w, err)
+		return
+	}
+
+	switch req.Encoding {
+	case "":
+	case "base64":
+		decoded, err := base64.StdEncoding.DecodeString(req.Value)
+		if err != nil {
+			respondError(w, http.StatusBadRequest, "Invalid base64 in 'value' field")
+			return
+		}
+		req.Value = string(decoded)
+	default:
+		respondError(w, http.StatusBadRequest, "Invalid encoding: must be base64")
+		return
+	}
+
+	// Invalid UTF-8 can arrive as raw bytes in the body, as a lone \uD800
+	// style escape, or inside decoded base64
+	if !utf8.Valid(raw) || hasUnpairedSurrogate(raw) || !utf8.ValidString(req.Value) {
+		if r.URL.Query().Get("sanitize") != "true" {
+			respondError(w, http.StatusBadRequest, "Value is not valid UTF-8")
+			return
+		}
+		// encoding/json has already replaced bad sequences in the body;
+		// converting through []rune does the same for decoded base64
+		req.Value = string([]rune(req.Value))
+	}
+
+	// The empty string is a valid value, but only on explicit request
+	allowEmpty := r.URL.Query().Get("allow_empty") == "true"
+
+	if req.Value == "" && !allowEmpty {
+		respondError(w, http.StatusBadRequest, "Missing 'value' field")
+		return
+	}
+
+	switch r.URL.Query().Get("normalize") {
+	case "":
+	case "whitespace":
+		req.Value = normalizeWhitespace(req.Value)
+		if req.Value == "" && !allowEmpty {
+			respondError(w, http.StatusBadRequest, "Value is empty after whitespace normalization")
+			return
+		}
+	case "nfc":
+		// Canonically equivalent spellings, such as a precomposed "é" and
+		// "e" plus a combining acute accent, collapse to one stored value
+		req.Value = norm.NFC.String(req.Value)
+	default:
+		respondError(w, http.StatusBadRequest, "Invalid normalize: must be whitespace or nfc")
+		return
+	}
+
+	if !h.checkLength(w, req.Value) {
+		return
+	}
+
+	analysis := NewStringAnalysis(req.Value, AnalysisOptions{PalindromeMode: palindromeMode, HashAlgorithm: h.config.HashAlgorithm})
+
+	caseInsensitive := r.URL.Query().Get("case_insensitive") == "true"
+
+	// existing names the stored value that blocked the create
+	existing := analysis.Value
+	if caseInsensitive {
+		existing, err = h.store.CreateCaseInsensitive(analysis)
+	} else {
+		err = h.store.Create(analysis)
+	}
+
+	if err != nil {
+		// With ?upsert=true a duplicate is not an error: the stored record is
+		// returned as-is so retries are safe
+		if r.URL.Query().Get("upsert") == "true" {
+			if stored, getErr := h.store.Get(existing); getErr == nil {
+				w.Header().Set("Location", resourcePath(stored))
+				respondJSON(w, http.StatusOK, selectProperties(stored, fields))
+				return
+			}
+		}
+		if caseInsensitive {
+			respondError(w, http.StatusConflict, fmt.Sprintf("String already exists as '%s'", existing))
+			return
+		}
+		respondError(w, http.StatusConflict, "String already exists")
+		return
+	}
+
+	h.metrics.StringsCreated(1)
+	w.Header().Set("Location", resourcePath(analysis))
+	respondJSON(w, http.StatusCreated, selectProperties(analysis, fields))
+}
+
+// hasUnpairedSurrogate reports whether a JSON document contains a \u escape
+// for a UTF-16 surrogate that is not part of a high/low pair.
+func hasUnpairedSurrogate(data []byte) bool {
+	surrogateAt := func(i int) (rune, bool) {
+		if i+6 > len(data) || data[i] != '\\' || data[i+1] != 'u' {
+			return 0, false
+		}
+		n, err := strconv.ParseUint(string(data[i+2:i+6]), 16, 16)
+		if err != nil || n < 0xD800 || n > 0xDFFF {
+			return 0, false
+		}
+		return rune(n), true
+	}
+
+	for i := 0; i < len(data); i++ {
+		if data[i] != '\\' {
+			continue
+		}
+		r, ok := surrogateAt(i)
+		if !ok {
+			// Skip the escaped character so \\uD800 is not read as an escape
+			i++
+			continue
+		}
+		if r >= 0xDC00 {
+			return true
+		}
+		if low, ok := surrogateAt(i + 6); !ok || low < 0xDC00 {
+			return true
+		}
+		i += 11
+	}
+	return false
+}
+
+// resourcePath is the URL of a stored string. The empty string has no path
+// segment of its own, so it is addressed by ID.
+func resourcePath(analysis *StringAnalysis) string {
+	if analysis.Value == "" {
+		return "/strings/by-id/" + analysis.ID
+	}
+	return "/strings/" + url.PathEscape(analysis.Value)
+}
+
+// normalizeWhitespace trims s and collapses every internal run of whitespace,
+// including newlines and tabs, to a single space.
+func normalizeWhitespace(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// BulkResult reports the outcome of creating a single value in a bulk request.
+type BulkResult struct {
+	Value  string `json:"value"`
+	Status string `json:"status"`
+	ID     string `json:"id,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+// BulkCreateString creates every value in the request body. Values that are
+// empty or already stored (including repeats within the batch) are reported
+// per item rather than failing the whole request.
+func (h *StringHandler) BulkCreateString(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxBulkBodyBytes)
+
+	// Each value is kept raw because encoding/json silently replaces invalid
+	// UTF-8 and unpaired surrogate escapes with U+FFFD while decoding
+	var req struct {
+		Values []json.RawMessage `json:"values"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondDecodeError(w, err)
+		return
+	}
+
+	if len(req.Values) == 0 {
+		respondError(w, http.StatusBadRequest, "Missing 'values' field")
+		return
+	}
+
+	values := make([]string, len(req.Values))
+	malformed := make([]bool, len(req.Values))
+	for i, raw := range req.Values {
+		if err := json.Unmarshal(raw, &values[i]); err != nil {
+			respondError(w, http.StatusUnprocessableEntity, "Invalid type for 'values' field")
+			return
+		}
+		malformed[i] = !utf8.Valid(raw) || hasUnpairedSurrogate(raw)
+	}
+
+	created, results := h.createMany(values, malformed, r.URL.Query().Get("sanitize") == "true")
+
+	response := map[string]interface{}{
+		"created": created,
+		"skipped": len(results) - created,
+		"results": results,
+	}
+
+	respondJSON(w, http.StatusMultiStatus, response)
+}
+
+// BatchGetStrings fetches every value in the request body in one store
+// lookup, reporting the stored ones keyed by value and the rest as missing.
+func (h *StringHandler) BatchGetStrings(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxBulkBodyBytes)
+
+	var req struct {
+		Values []string `json:"values"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondDecodeError(w, err)
+		return
+	}
+
+	if len(req.Values) == 0 {
+		respondError(w, http.StatusBadRequest, "Missing 'values' field")
+		return
+	}
+
+	found, err := h.store.GetMany(req.Values)
+	if err != nil {
+		id := RequestIDFromContext(r.Context())
+		slog.Error("failed to fetch strings", "request_id", id, "error", err)
+		respondError(w, http.StatusInternalServerError, "Failed to fetch strings (request ID "+id+")")
+		return
+	}
+
+	// Missing values keep their request order, each listed once
+	missing := []string{}
+	listed := make(map[string]bool)
+	for _, value := range req.Values {
+		if _, ok := found[value]; !ok && !listed[value] {
+			missing = append(missing, value)
+			listed[value] = true
+		}
+	}
+
+	response := map[string]interface{}{
+		"found":   found,
+		"missing": missing,
+	}
+
+	respondJSON(w, http.StatusOK, response)
+}
+
+// createMany stores each value, returning the number created and a result
+// per value. Empty, oversized, duplicate and invalid UTF-8 values are reported
+// rather than aborting the batch. malformed, when not nil, flags values that
+// were already repaired while decoding; with sanitize, invalid bytes are
+// replaced with U+FFFD and the value is stored instead.
+func (h *StringHandler) createMany(values []string, malformed []bool, sanitize bool) (int, []BulkResult) {
+	created := 0
+	results := make([]BulkResult, 0, len(values))
+
+	for i, value := range values {
+		if (malformed != nil && malformed[i]) || !utf8.ValidString(value) {
+			if !sanitize {
+				results = append(results, BulkResult{Value: value, Status: "invalid", Error: "Value is not valid UTF-8"})
+				continue
+			}
+			value = string([]rune(value))
+		}
+
+		if value == "" {
+			results = append(results, BulkResult{Value: value, Status: "invalid", Error: "Empty value"})
+			continue
+		}
+
+		if utf8.RuneCountInString(value) > h.config.MaxStringLength {
+			results = append(results, BulkResult{
+				Value:  value,
+				Status: "invalid",
+				Error:  fmt.Sprintf("Value exceeds maximum length of %d characters", h.config.MaxStringLength),
+			})
+			continue
+		}
+
+		analysis := NewStringAnalysis(value, AnalysisOptions{HashAlgorithm: h.config.HashAlgorithm})
+		if err := h.store.Create(analysis); err != nil {
+			results = append(results, BulkResult{Value: value, Status: "conflict", Error: "String already exists"})
+			continue
+		}
+
+		created++
+		results = append(results, BulkResult{Value: value, Status: "created", ID: analysis.ID})
+	}
+
+	h.metrics.StringsCreated(created)
+
+	return created, results
+}
+
+// ImportStrings stores each non-blank line of a text/plain body, tolerating
+// Windows line endings, and reports how many were created or skipped.
+func (h *StringHandler) ImportStrings(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBulkBodyBytes))
+	if err != nil {
+		respondDecodeError(w, err)
+		return
+	}
+
+	var lines []string
+	for _, line := range strings.Split(string(body), "\n") {
+		line = strings.TrimSuffix(line, "\r")
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		lines = append(lines, line)
+	}
+
+	if len(lines) == 0 {
+		respondError(w, http.StatusBadRequest, "Request body has no lines to import")
+		return
+	}
+
+	created, results := h.createMany(lines, nil, r.URL.Query().Get("sanitize") == "true")
+
+	conflicts := []string{}
+	for _, result := range results {
+		if result.Status == "conflict" {
+			conflicts = append(conflicts, result.Value)
+		}
+	}
+
+	response := map[string]interface{}{
+		"created":   created,
+		"skipped":   len(results) - created,
+		"conflicts": conflicts,
+	}
+
+	respondJSON(w, http.StatusOK, response)
+}
+
+// ExportStrings downloads every stored string, as JSON by default or as CSV
+// with ?format=csv.
+func (h *StringHandler) ExportStrings(w http.ResponseWriter, r *http.Request) {
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = "json"
+	}
+	if format != "json" && format != "csv" {
+		respondError(w, http.StatusBadRequest, "Invalid format: must be json or csv")
+		return
+	}
+
+	results := h.store.GetAll(map[string]interface{}{})
+	sortResults(results, "created_at", false)
+
+	if format == "json" {
+		w.Header().Set("Content-Disposition", `attachment; filename="strings.json"`)
+		respondJSON(w, http.StatusOK, results)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", `attachment; filename="strings.csv"`)
+	w.WriteHeader(http.StatusOK)
+
+	// encoding/csv quotes values containing commas, quotes or newlines
+	writer := csv.NewWriter(w)
+	writer.Write([]string{"value", "length", "is_palindrome", "unique_characters", "word_count", "sha256_hash", "created_at", "updated_at"})
+	for _, analysis := range results {
+		writer.Write([]string{
+			analysis.Value,
+			strconv.Itoa(analysis.Properties.Length),
+			strconv.FormatBool(analysis.Properties.IsPalindrome),
+			strconv.Itoa(analysis.Properties.UniqueCharacters),
+			strconv.Itoa(analysis.Properties.WordCount),
+			analysis.Properties.SHA256Hash,
+			analysis.CreatedAt,
+			analysis.UpdatedAt,
+		})
+	}
+	writer.Flush()
+
+	if err := writer.Error(); err != nil {
+		slog.Error("failed to write CSV export", "error", err)
+	}
+}
+
+func (h *StringHandler) GetString(w http.ResponseWriter, r *http.Request) {
+	value, err := pathValue(r, "/strings/", "")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid string value encoding")
+		return
+	}
+
+	if value == "" || value == "strings" {
+		respondError(w, http.StatusBadRequest, "String value required")
+		return
+	}
+
+	get := h.store.Get
+	if r.URL.Query().Get("include_deleted") == "true" {
+		get = h.store.GetIncludingDeleted
+	}
+
+	analysis, err := get(value)
+	if err != nil {
+		respondError(w, http.StatusNotFound, "String not found")
+		return
+	}
+
+	etag := entityTag(analysis)
+	w.Header().Set("ETag", etag)
+	if etagMatches(r.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, analysis)
+}
+
+// entityTag is the strong validator for a stored entry. The ID only names
+// the content, so the palindrome mode and the update and deletion times are
+// folded in to change the tag whenever the stored record changes.
+func entityTag(analysis *StringAnalysis) string {
+	tag := analysis.ID + "-" + analysis.Properties.PalindromeMode + "-" + analysis.UpdatedAt
+	if analysis.Deleted() {
+		tag += "-" + analysis.DeletedAt
+	}
+	return `"` + tag + `"`
+}
+
+// etagMatches reports whether an If-None-Match header value matches etag.
+// The header may be "*" or a comma-separated list of tags; weak tags compare
+// equal to their strong form, as RFC 9110 requires for If-None-Match.
+func etagMatches(header, etag string) bool {
+	if header == "" {
+		return false
+	}
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" || strings.TrimPrefix(tag, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// StringExists answers HEAD /strings/{value} and GET or HEAD
+// /strings/{value}/exists with 200 or 404 and no body, for clients that only
+// need to poll presence.
+func (h *StringHandler) StringExists(w http.ResponseWriter, r *http.Request) {
+	// The path is matched as the router matched it, so either method on the
+	// /exists route drops the suffix
+	suffix := ""
+	if pathSuffix("/exists")(r.URL.EscapedPath()) {
+		suffix = "/exists"
+	}
+
+	value, err := pathValue(r, "/strings/", suffix)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	if _, err := h.store.Get(value); err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// GetCharacterFrequency returns only the character frequency map of a stored
+// value, or with ?sorted=true an array of {char, count} ordered by count
+// descending.
+func (h *StringHandler) GetCharacterFrequency(w http.ResponseWriter, r *http.Request) {
+	value, err := pathValue(r, "/strings/", "/frequency")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid string value encoding")
+		return
+	}
+
+	analysis, err := h.store.Get(value)
+	if err != nil {
+		respondError(w, http.StatusNotFound, "String not found")
+		return
+	}
+
+	if r.URL.Query().Get("sorted") == "true" {
+		respondJSON(w, http.StatusOK, sortedFrequencies(analysis.Properties.CharacterFrequencyMap))
+		return
+	}
+
+	respondJSON(w, http.StatusOK, analysis.Properties.CharacterFrequencyMap)
+}
+
+// GetPalindromicSubstrings lists the distinct palindromic substrings of a
+// stored value that are at least ?min= characters long (default 2), a page at
+// a time with limit and offset as for GET /strings.
+func (h *StringHandler) GetPalindromicSubstrings(w http.ResponseWriter, r *http.Request) {
+	value, err := pathValue(r, "/strings/", "/palindromes")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid string value encoding")
+		return
+	}
+
+	minLength := 2
+	if val := r.URL.Query().Get("min"); val != "" {
+		i, err := parseInt(val)
+		if err != nil || i < 1 {
+			respondError(w, http.StatusBadRequest, "invalid min: "+val)
+			return
+		}
+		minLength = i
+	}
+
+	// A long run of one character has a palindrome ending at every position,
+	// so the listing is paged rather than sent whole
+	limit, offset, err := parsePagination(r.URL.Query())
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	analysis, err := h.store.Get(value)
+	if err != nil {
+		respondError(w, http.StatusNotFound, "String not found")
+		return
+	}
+
+	palindromes := palindromicSubstrings(analysis.Value, minLength)
+	total := len(palindromes)
+	page := palindromes[min(offset, total):min(offset+limit, total)]
+
+	response := map[string]interface{}{
+		"value":  analysis.Value,
+		"data":   page,
+		"count":  len(page),
+		"total":  total,
+		"limit":  limit,
+		"offset": offset,
+	}
+
+	respondJSON(w, http.StatusOK, response)
+}
+
+// GetStringByID looks an entry up by its SHA-256 ID, so clients don't need to
+// URL-encode arbitrary values.
+func (h *StringHandler) GetStringByID(w http.ResponseWriter, r *http.Request) {
+	id, err := pathValue(r, "/strings/by-id/", "")
+	if err != nil || id == "" {
+		respondError(w, http.StatusBadRequest, "String id required")
+		return
+	}
+
+	analysis, err := h.store.GetByID(id)
+	if err != nil {
+		respondError(w, http.StatusNotFound, "String not found")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, analysis)
+}
+
+// AppliedFilters echoes the query filters GET /strings applied. Unset filters
+// are omitted.
+type AppliedFilters struct {
+	IsPalindrome      *bool    `json:"is_palindrome,omitempty"`
+	IsPangram         *bool    `json:"is_pangram,omitempty"`
+	MinLength         *int     `json:"min_length,omitempty"`
+	MaxLength         *int     `json:"max_length,omitempty"`
+	WordCount         *int     `json:"word_count,omitempty"`
+	MinWordCount      *int     `json:"min_word_count,omitempty"`
+	MaxWordCount      *int     `json:"max_word_count,omitempty"`
+	ContainsCharacter []string `json:"contains_character,omitempty"`
+	ExcludesCharacter []string `json:"excludes_character,omitempty"`
+	ContainsSubstring string   `json:"contains_substring,omitempty"`
+	CaseInsensitive   bool     `json:"case_insensitive,omitempty"`
+	StartsWith        string   `json:"starts_with,omitempty"`
+	EndsWith          string   `json:"ends_with,omitempty"`
+	MinUniqueChars    *int     `json:"min_unique_chars,omitempty"`
+	MaxUniqueChars    *int     `json:"max_unique_chars,omitempty"`
+	MinEntropy        *float64 `json:"min_entropy,omitempty"`
+	MaxEntropy        *float64 `json:"max_entropy,omitempty"`
+	Pattern           string   `json:"pattern,omitempty"`
+	CreatedAfter      string   `json:"created_after,omitempty"`
+	CreatedBefore     string   `json:"created_before,omitempty"`
+	IncludeDeleted    bool     `json:"include_deleted,omitempty"`
+}
+
+func boolPtr(b bool) *bool {
+	return &b
+}
+
+// filterParams lists the query parameters parseFilters reads, so DELETE on
+// the collection can reject anything else rather than ignore a typo.
+var filterParams = []string{
+	"is_palindrome", "is_pangram", "word_count",
+	"contains_character", "excludes_character",
+	"contains_substring", "case_insensitive", "starts_with", "ends_with",
+	"min_length", "max_length", "min_word_count", "max_word_count",
+	"min_unique_chars", "max_unique_chars", "min_entropy", "max_entropy",
+	"pattern", "created_after", "created_before", "include_deleted",
+}
+
+// parseFilters reads the filter query parameters shared by GET and DELETE
+// on the collection. It returns the filters for matchesFilters alongside the
+// typed summary echoed back to clients.
+func parseFilters(query url.Values) (map[string]interface{}, AppliedFilters, error) {
+	filters := make(map[string]interface{})
+	var applied AppliedFilters
+
+	for _, param := range []struct {
+		name string
+		dst  **bool
+	}{
+		{"is_palindrome", &applied.IsPalindrome},
+		{"is_pangram", &applied.IsPangram},
+	} {
+		b, err := parseBoolParam(query, param.name)
+		if err != nil {
+			return nil, AppliedFilters{}, err
+		}
+		if b != nil {
+			filters[param.name] = *b
+			*param.dst = b
+		}
+	}
+
+	if val := query.Get("word_count"); val != "" {
+		i, err := parseInt(val)
+		if err != nil || i < 0 {
+			return nil, AppliedFilters{}, errors.New("invalid word_count: " + val)
+		}
+		filters["word_count"] = i
+		applied.WordCount = &i
+	}
+
+	for _, param := range []struct {
+		name string
+		dst  *[]string
+	}{
+		{"contains_character", &applied.ContainsCharacter},
+		{"excludes_character", &applied.ExcludesCharacter},
+	} {
+		var chars []string
+		for _, val := range query[param.name] {
+			if val != "" {
+				chars = append(chars, val)
+			}
+		}
+		if len(chars) > 0 {
+			filters[param.name] = chars
+			*param.dst = chars
+		}
+	}
+
+	caseInsensitive, err := parseBoolParam(query, "case_insensitive")
+	if err != nil {
+		return nil, AppliedFilters{}, err
+	}
+	if val := query.Get("contains_substring"); val != "" {
+		filters["contains_substring"] = val
+		applied.ContainsSubstring = val
+
+		if caseInsensitive != nil && *caseInsensitive {
+			filters["case_insensitive"] = true
+			applied.CaseInsensitive = true
+		}
+	}
+
+	if val := query.Get("starts_with"); val != "" {
+		filters["starts_with"] = val
+		applied.StartsWith = val
+	}
+
+	if val := query.Get("ends_with"); val != "" {
+		filters["ends_with"] = val
+		applied.EndsWith = val
+	}
+
+	for _, param := range []struct {
+		name string
+		dst  **int
+	}{
+		{"min_length", &applied.MinLength},
+		{"max_length", &applied.MaxLength},
+		{"min_word_count", &applied.MinWordCount},
+		{"max_word_count", &applied.MaxWordCount},
+		{"min_unique_chars", &applied.MinUniqueChars},
+		{"max_unique_chars", &applied.MaxUniqueChars},
+	} {
+		if val := query.Get(param.name); val != "" {
+			i, err := parseInt(val)
+			if err != nil || i < 0 {
+				return nil, AppliedFilters{}, errors.New("invalid " + param.name + ": " + val)
+			}
+			filters[param.name] = i
+			*param.dst = &i
+		}
+	}
+
+	for _, param := range []struct {
+		name string
+		dst  **float64
+	}{
+		{"min_entropy", &applied.MinEntropy},
+		{"max_entropy", &applied.MaxEntropy},
+	} {
+		if val := query.Get(param.name); val != "" {
+			f, err := strconv.ParseFloat(val, 64)
+			if err != nil || math.IsNaN(f) {
+				return nil, AppliedFilters{}, errors.New("invalid " + param.name + ": " + val)
+			}
+			filters[param.name] = f
+			*param.dst = &f
+		}
+	}
+
+	if val := query.Get("pattern"); val != "" {
+		// Go's regexp is RE2 (linear time, no backtracking); cap the pattern
+		// size so a client cannot make compilation itself expensive.
+		if len(val) > maxPatternLength {
+			return nil, AppliedFilters{}, fmt.Errorf("pattern exceeds %d bytes", maxPatternLength)
+		}
+		re, err := regexp.Compile(val)
+		if err != nil {
+			return nil, AppliedFilters{}, errors.New("invalid pattern: " + err.Error())
+		}
+		filters["pattern"] = re
+		applied.Pattern = val
+	}
+
+	for _, param := range []struct {
+		name string
+		dst  *string
+	}{
+		{"created_after", &applied.CreatedAfter},
+		{"created_before", &applied.CreatedBefore},
+	} {
+		if val := query.Get(param.name); val != "" {
+			t, err := time.Parse(time.RFC3339, val)
+			if err != nil {
+				return nil, AppliedFilters{}, errors.New("invalid " + param.name + ": must be an RFC3339 timestamp")
+			}
+			filters[param.name] = t
+			*param.dst = val
+		}
+	}
+
+	includeDeleted, err := parseBoolParam(query, "include_deleted")
+	if err != nil {
+		return nil, AppliedFilters{}, err
+	}
+	if includeDeleted != nil && *includeDeleted {
+		filters["include_deleted"] = true
+		applied.IncludeDeleted = true
+	}
+
+	return filters, applied, nil
+}
+
+// parseBoolParam reads a query parameter that must be "true" or "false",
+// returning nil when it is absent or empty.
+func parseBoolParam(query url.Values, name string) (*bool, error) {
+	switch val := query.Get(name); val {
+	case "":
+		return nil, nil
+	case "true":
+		return boolPtr(true), nil
+	case "false":
+		return boolPtr(false), nil
+	default:
+		return nil, errors.New("invalid " + name + ": must be true or false")
+	}
+}
+
+func (h *StringHandler) GetAllStrings(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	filters, applied, err := parseFilters(query)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if query.Get("count_only") == "true" {
+		count := 0
+		if err := h.store.Each(filters, func(*StringAnalysis) error {
+			count++
+			return nil
+		}); err != nil {
+			id := RequestIDFromContext(r.Context())
+			slog.Error("failed to count strings", "request_id", id, "error", err)
+			respondError(w, http.StatusInternalServerError, "Failed to count strings (request ID "+id+")")
+			return
+		}
+
+		respondJSON(w, http.StatusOK, map[string]interface{}{
+			"count":           count,
+			"filters_applied": applied,
+		})
+		return
+	}
+
+	if wantsNDJSON(r) {
+		h.streamNDJSON(w, filters)
+		return
+	}
+
+	limit, offset, err := parsePagination(query)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	sortBy := query.Get("sort_by")
+	if sortBy == "" {
+		sortBy = "created_at"
+	}
+	if _, ok := sortFields[sortBy]; !ok {
+		respondError(w, http.StatusBadRequest, "Invalid sort_by: must be one of "+strings.Join(sortFieldNames, ", "))
+		return
+	}
+
+	order := query.Get("order")
+	if order == "" {
+		order = "asc"
+	}
+	if order != "asc" && order != "desc" {
+		respondError(w, http.StatusBadRequest, "Invalid order: must be asc or desc")
+		return
+	}
+
+	results := h.store.GetAll(filters)
+	sortResults(results, sortBy, order == "desc")
+
+	total := len(results)
+	page := paginate(results, limit, offset)
+
+	response := map[string]interface{}{
+		"data":            page,
+		"count":           len(page),
+		"total":           total,
+		"limit":           limit,
+		"offset":          offset,
+		"filters_applied": applied,
+	}
+
+	respondJSON(w, http.StatusOK, response)
+}
+
+// ndjsonFlushEvery is how many records streamNDJSON writes between flushes.
+const ndjsonFlushEvery = 100
+
+// wantsNDJSON reports whether the client asked for newline-delimited JSON,
+// either with ?format=ndjson or an Accept: application/x-ndjson header.
+func wantsNDJSON(r *http.Request) bool {
+	if r.URL.Query().Get("format") == "ndjson" {
+		return true
+	}
+	return strings.Contains(r.Header.Get("Accept"), "application/x-ndjson")
+}
+
+// streamNDJSON writes every matching string as one JSON object per line,
+// encoding each as it comes off the store instead of building the full
+// result set. Records arrive in store order; pagination and sorting are not
+// applied.
+func (h *StringHandler) streamNDJSON(w http.ResponseWriter, filters map[string]interface{}) {
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.WriteHeader(http.StatusOK)
+
+	flusher, _ := w.(http.Flusher)
+	encoder := json.NewEncoder(w)
+	written := 0
+
+	err := h.store.Each(filters, func(analysis *StringAnalysis) error {
+		if err := encoder.Encode(analysis); err != nil {
+			return err
+		}
+		written++
+		if flusher != nil && written%ndjsonFlushEvery == 0 {
+			flusher.Flush()
+		}
+		return nil
+	})
+	if err != nil {
+		// The status line is already sent, so all we can do is stop.
+		slog.Warn("NDJSON stream aborted", "records_written", written, "error", err)
+		return
+	}
+
+	if flusher != nil {
+		flusher.Flush()
+	}
+}
+
+// SimilarString pairs a stored string with its edit distance from the query.
+type SimilarString struct {
+	Value    string `json:"value"`
+	ID       string `json:"id"`
+	Distance int    `json:"distance"`
+}
+
+// GetSimilarStrings returns the stored strings closest to the path value by
+// Levenshtein distance, nearest first.
+func (h *StringHandler) GetSimilarStrings(w http.ResponseWriter, r *http.Request) {
+	value, err := pathValue(r, "/strings/", "/similar")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid string value encoding")
+		return
+	}
+	if value == "" {
+		respondError(w, http.StatusBadRequest, "String value required")
+		return
+	}
+
+	// Every comparison costs len(value)·len(candidate), so value is bounded
+	// like a stored one
+	if !h.checkLength(w, value) {
+		return
+	}
+
+	query := r.URL.Query()
+
+	limit := 5
+	if val := query.Get("limit"); val != "" {
+		i, err := parseInt(val)
+		if err != nil || i < 1 {
+			respondError(w, http.StatusBadRequest, "invalid limit: "+val)
+			return
+		}
+		limit = i
+	}
+
+	maxDistance := -1
+	if val := query.Get("max_distance"); val != "" {
+		i, err := parseInt(val)
+		if err != nil || i < 0 {
+			respondError(w, http.StatusBadRequest, "invalid max_distance: "+val)
+			return
+		}
+		maxDistance = i
+	}
+
+	valueLength := utf8.RuneCountInString(value)
+
+	matches := []SimilarString{}
+	for _, analysis := range h.store.GetAll(map[string]interface{}{}) {
+		if analysis.Value == value {
+			continue
+		}
+		// The length difference is a lower bound on the edit distance, so
+		// candidates it already rules out skip the full computation
+		if maxDistance >= 0 {
+			diff := utf8.RuneCountInString(analysis.Value) - valueLength
+			if diff > maxDistance || -diff > maxDistance {
+				continue
+			}
+		}
+		distance := levenshtein(value, analysis.Value)
+		if maxDistance >= 0 && distance > maxDistance {
+			continue
+		}
+		matches = append(matches, SimilarString{Value: analysis.Value, ID: analysis.ID, Distance: distance})
+	}
+
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].Distance != matches[j].Distance {
+			return matches[i].Distance < matches[j].Distance
+		}
+		return matches[i].Value < matches[j].Value
+	})
+
+	if len(matches) > limit {
+		matches = matches[:limit]
+	}
+
+	response := map[string]interface{}{
+		"value": value,
+		"data":  matches,
+		"count": len(matches),
+	}
+
+	respondJSON(w, http.StatusOK, response)
+}
+
+// AnagramGroup lists stored values that share the same anagram signature.
+type AnagramGroup struct {
+	Signature string   `json:"signature"`
+	Values    []string `json:"values"`
+}
+
+// GetAnagramGroups groups stored strings that are anagrams of each other,
+// returning only groups with at least two members.
+func (h *StringHandler) GetAnagramGroups(w http.ResponseWriter, r *http.Request) {
+	bySignature := make(map[string][]string)
+	for _, analysis := range h.store.GetAll(map[string]interface{}{}) {
+		sig := anagramSignature(analysis.Value)
+		bySignature[sig] = append(bySignature[sig], analysis.Value)
+	}
+
+	groups := []AnagramGroup{}
+	for sig, values := range bySignature {
+		if len(values) < 2 {
+			continue
+		}
+		sort.Strings(values)
+		groups = append(groups, AnagramGroup{Signature: sig, Values: values})
+	}
+
+	sort.Slice(groups, func(i, j int) bool {
+		return groups[i].Signature < groups[j].Signature
+	})
+
+	response := map[string]interface{}{
+		"data":  groups,
+		"count": len(groups),
+	}
+
+	respondJSON(w, http.StatusOK, response)
+}
+
+// DuplicateGroup lists stored values that share the same ID.
+type DuplicateGroup struct {
+	ID     string   `json:"id"`
+	Values []string `json:"values"`
+}
+
+// GetDuplicateIDs reports IDs mapped to more than one stored value. With
+// SHA-256 this should always be empty; entries written by older versions,
+// which used a weak polynomial hash, can still collide.
+func (h *StringHandler) GetDuplicateIDs(w http.ResponseWriter, r *http.Request) {
+	byID := make(map[string][]string)
+	err := h.store.Each(map[string]interface{}{}, func(analysis *StringAnalysis) error {
+		byID[analysis.ID] = append(byID[analysis.ID], analysis.Value)
+		return nil
+	})
+	if err != nil {
+		id := RequestIDFromContext(r.Context())
+		slog.Error("failed to scan strings for duplicate IDs", "request_id", id, "error", err)
+		respondError(w, http.StatusInternalServerError, "Failed to scan strings (request ID "+id+")")
+		return
+	}
+
+	groups := []DuplicateGroup{}
+	for id, values := range byID {
+		if len(values) < 2 {
+			continue
+		}
+		sort.Strings(values)
+		groups = append(groups, DuplicateGroup{ID: id, Values: values})
+	}
+
+	sort.Slice(groups, func(i, j int) bool {
+		return groups[i].ID < groups[j].ID
+	})
+
+	response := map[string]interface{}{
+		"data":  groups,
+		"count": len(groups),
+	}
+
+	respondJSON(w, http.StatusOK, response)
+}
+
+// readyProbePrefix starts every sentinel value /ready creates.
+const readyProbePrefix = "__ready_probe_"
+
+// Ready is a readiness check: unlike /health it only answers 200 once the
+// store has proved it works, and otherwise answers 503 with the failing step.
+// Persistent stores check themselves with a Probe that leaves their contents
+// alone. The memory store instead creates, reads and deletes a sentinel
+// value, hard-deleted whatever the outcome so it never lingers; when it is
+// at its MAX_ENTRIES capacity the round trip is skipped, since creating the
+// sentinel would evict a real entry.
+func (h *StringHandler) Ready(w http.ResponseWriter, r *http.Request) {
+	var checks map[string]string
+	var err error
+	if prober, ok := h.store.(interface {
+		Probe() (map[string]string, error)
+	}); ok {
+		checks, err = prober.Probe()
+	} else if full, ok := h.store.(interface{ Full() bool }); ok && full.Full() {
+		respondJSON(w, http.StatusOK, map[string]interface{}{
+			"status": "ready",
+			"checks": map[string]string{"store": "skipped: store is at MAX_ENTRIES capacity"},
+		})
+		return
+	} else {
+		checks, err = probeStore(h.store, readyProbePrefix+newRequestID(), AnalysisOptions{HashAlgorithm: h.config.HashAlgorithm})
+	}
+	if err != nil {
+		id := RequestIDFromContext(r.Context())
+		slog.Error("readiness check failed", "request_id", id, "error", err)
+		respondJSON(w, http.StatusServiceUnavailable, map[string]interface{}{
+			"status": "not ready",
+			"checks": checks,
+		})
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"status": "ready",
+		"checks": checks,
+	})
+}
+
+// probeStore creates value analyzed with opts, reads it, soft-deletes it and
+// finally removes it, recording "ok" or the error for each step. Steps after
+// a failure are not attempted, except the removal.
+func probeStore(store Store, value string, opts AnalysisOptions) (map[string]string, error) {
+	checks := make(map[string]string)
+	steps := []struct {
+		name string
+		run  func() error
+	}{
+		{"create", func() error {
+			return store.Create(NewStringAnalysis(value, opts))
+		}},
+		{"get", func() error {
+			analysis, err := store.Get(value)
+			if err == nil && analysis.Value != value {
+				err = fmt.Errorf("got %q back", analysis.Value)
+			}
+			return err
+		}},
+		{"delete", func() error {
+			return store.Delete(value)
+		}},
+	}
+
+	var failed error
+	for _, step := range steps {
+		if err := step.run(); err != nil {
+			checks[step.name] = err.Error()
+			failed = fmt.Errorf("%s: %w", step.name, err)
+			break
+		}
+		checks[step.name] = "ok"
+	}
+
+	// Delete leaves a tombstone, so the sentinel is always removed outright.
+	// A failed create leaves nothing to remove.
+	if checks["create"] == "ok" {
+		if err := store.Remove(value); err != nil {
+			checks["cleanup"] = err.Error()
+			if failed == nil {
+				failed = fmt.Errorf("cleanup: %w", err)
+			}
+		} else {
+			checks["cleanup"] = "ok"
+		}
+	}
+
+	return checks, failed
+}
+
+// Stats aggregates properties across every stored string.
+type Stats struct {
+	TotalCount         int            `json:"total_count"`
+	AverageLength      float64        `json:"average_length"`
+	MinLength          int            `json:"min_length"`
+	MaxLength          int            `json:"max_length"`
+	PalindromeCount    int            `json:"palindrome_count"`
+	AverageWordCount   float64        `json:"average_word_count"`
+	CharacterFrequency map[string]int `json:"character_frequency"`
+}
+
+// StringDiff describes how two strings differ at the character level.
+type StringDiff struct {
+	A                   string `json:"a"`
+	B                   string `json:"b"`
+	Identical           bool   `json:"identical"`
+	CommonPrefixLength  int    `json:"common_prefix_length"`
+	CommonSuffixLength  int    `json:"common_suffix_length"`
+	LevenshteinDistance int    `json:"levenshtein_distance"`
+	IsAnagram           bool   `json:"is_anagram"`
+}
+
+// DiffStrings compares the a and b query parameters. Neither needs to be
+// stored.
+func (h *StringHandler) DiffStrings(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	if !query.Has("a") || !query.Has("b") {
+		respondError(w, http.StatusBadRequest, "Both 'a' and 'b' query parameters are required")
+		return
+	}
+	a, b := query.Get("a"), query.Get("b")
+
+	// The edit distance costs len(a)·len(b), so both sides are bounded
+	if !h.checkLength(w, a) || !h.checkLength(w, b) {
+		return
+	}
+
+	prefix, suffix := commonAffixLengths(a, b)
+	respondJSON(w, http.StatusOK, StringDiff{
+		A:                   a,
+		B:                   b,
+		Identical:           a == b,
+		CommonPrefixLength:  prefix,
+		CommonSuffixLength:  suffix,
+		LevenshteinDistance: levenshtein(a, b),
+		IsAnagram:           anagramSignature(a) == anagramSignature(b),
+	})
+}
+
+// commonAffixLengths returns the lengths, in runes, of the longest common
+// prefix and suffix of a and b. The suffix never overlaps the prefix, so for
+// identical strings the suffix is 0.
+func commonAffixLengths(a, b string) (int, int) {
+	ra, rb := []rune(a), []rune(b)
+	n := min(len(ra), len(rb))
+
+	prefix := 0
+	for prefix < n && ra[prefix] == rb[prefix] {
+		prefix++
+	}
+
+	suffix := 0
+	for suffix < n-prefix && ra[len(ra)-1-suffix] == rb[len(rb)-1-suffix] {
+		suffix++
+	}
+
+	return prefix, suffix
+}
+
+// CommonSubstring is the longest run of characters shared by two strings.
+type CommonSubstring struct {
+	A         string `json:"a"`
+	B         string `json:"b"`
+	Substring string `json:"substring"`
+	Length    int    `json:"length"`
+}
+
+// LongestCommonSubstring finds the longest common substring of the a and b
+// query parameters. Neither needs to be stored, but each is bounded by
+// MaxStringLength to keep the comparison table small.
+func (h *StringHandler) LongestCommonSubstring(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	if !query.Has("a") || !query.Has("b") {
+		respondError(w, http.StatusBadRequest, "Both 'a' and 'b' query parameters are required")
+		return
+	}
+	a, b := query.Get("a"), query.Get("b")
+
+	if !h.checkLength(w, a) || !h.checkLength(w, b) {
+		return
+	}
+
+	substring := longestCommonSubstring(a, b)
+	respondJSON(w, http.StatusOK, CommonSubstring{
+		A:         a,
+		B:         b,
+		Substring: substring,
+		Length:    utf8.RuneCountInString(substring),
+	})
+}
+
+// PalindromeSubstring is one palindromic substring and the rune index of its
+// first occurrence.
+type PalindromeSubstring struct {
+	Substring string `json:"substring"`
+	Start     int    `json:"start"`
+	Length    int    `json:"length"`
+}
+
+// palindromicSubstrings returns the distinct palindromic substrings of s with
+// at least minLength runes, by first occurrence, ordered by start index and
+// then length. Runes are compared exactly.
+//
+// Expanding around each center records the longest palindrome ending at
+// every position. Only those can be first occurrences: a shorter palindrome
+// ending at the same position is a suffix, and so also a prefix, of the longer
+// one, which means it already appeared further left.
+//
+// Substrings shorter than minLength are dropped before anything is built, and
+// the rest slice s rather than copy it, so a long run of one repeated
+// character costs no more than the listing itself.
+func palindromicSubstrings(s string, minLength int) []PalindromeSubstring {
+	runes := []rune(s)
+	n := len(runes)
+
+	// offsets[i] is the byte offset of rune i, with offsets[n] == len(s)
+	offsets := make([]int, 0, n+1)
+	for i := range s {
+		offsets = append(offsets, i)
+	}
+	offsets = append(offsets, len(s))
+
+	longest := make([]int, n)
+	for center := 0; center < 2*n-1; center++ {
+		left, right := center/2, (center+1)/2
+		for left >= 0 && right < n && runes[left] == runes[right] {
+			longest[right] = max(longest[right], right-left+1)
+			left--
+			right++
+		}
+	}
+
+	seen := make(map[string]bool)
+	found := []PalindromeSubstring{}
+	for end, length := range longest {
+		if length < minLength {
+			continue
+		}
+		start := end - length + 1
+		substring := s[offsets[start]:offsets[end+1]]
+		if seen[substring] {
+			continue
+		}
+		seen[substring] = true
+
+		found = append(found, PalindromeSubstring{Substring: substring, Start: start, Length: length})
+	}
+
+	sort.Slice(found, func(i, j int) bool {
+		if found[i].Start != found[j].Start {
+			return found[i].Start < found[j].Start
+		}
+		return found[i].Length < found[j].Length
+	})
+
+	return found
+}
+
+// longestCommonSubstring returns the longest substring of both a and b,
+// compared rune by rune, preferring the earliest in a on ties. It keeps two
+// rows of the dynamic-programming table, where each cell holds the length of
+// the common run ending at that pair of positions.
+func longestCommonSubstring(a, b string) string {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+
+	best, end := 0, 0
+	for i := 1; i <= len(ra); i++ {
+		for j := 1; j <= len(rb); j++ {
+			if ra[i-1] == rb[j-1] {
+				curr[j] = prev[j-1] + 1
+				if curr[j] > best {
+					best, end = curr[j], i
+				}
+			} else {
+				curr[j] = 0
+			}
+		}
+		prev, curr = curr, prev
+	}
+
+	return string(ra[end-best : end])
+}
+
+// GetRandomStrings returns one uniformly random stored string, or with
+// ?count=N a list of up to N distinct random strings.
+func (h *StringHandler) GetRandomStrings(w http.ResponseWriter, r *http.Request) {
+	count := 0
+	if val := r.URL.Query().Get("count"); val != "" {
+		i, err := parseInt(val)
+		if err != nil || i <= 0 {
+			respondError(w, http.StatusBadRequest, "invalid count: "+val)
+			return
+		}
+		count = min(i, maxPageLimit)
+	}
+
+	// GetAll snapshots the entries under the store's read lock, so the
+	// selection below works on a stable slice despite map iteration order.
+	all := h.store.GetAll(map[string]interface{}{})
+	if len(all) == 0 {
+		respondError(w, http.StatusNotFound, "No strings stored")
+		return
+	}
+
+	if count == 0 {
+		respondJSON(w, http.StatusOK, all[mathrand.Intn(len(all))])
+		return
+	}
+
+	// A partial Fisher-Yates shuffle picks count distinct entries
+	count = min(count, len(all))
+	for i := 0; i < count; i++ {
+		j := i + mathrand.Intn(len(all)-i)
+		all[i], all[j] = all[j], all[i]
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"data":  all[:count],
+		"count": count,
+	})
+}
+
+// SearchResult is a stored string matching a search query, with its
+// relevance score.
+type SearchResult struct {
+	*StringAnalysis
+	Score int `json:"score"`
+}
+
+// searchScore ranks how well value matches query: 3 for an exact match, 2
+// for a prefix, 1 for any other substring and 0 for no match.
+func searchScore(value, query string, caseInsensitive bool) int {
+	if caseInsensitive {
+		value, query = strings.ToLower(value), strings.ToLower(query)
+	}
+
+	switch {
+	case value == query:
+		return 3
+	case strings.HasPrefix(value, query):
+		return 2
+	case strings.Contains(value, query):
+		return 1
+	}
+	return 0
+}
+
+// SearchStrings returns the stored strings containing q, most relevant
+// first. Equal scores are ordered shortest value first, since a shorter
+// value is a closer match, then alphabetically.
+func (h *StringHandler) SearchStrings(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	q := query.Get("q")
+	if q == "" {
+		respondError(w, http.StatusBadRequest, "Query parameter 'q' is required")
+		return
+	}
+
+	caseInsensitive := query.Get("case_insensitive") == "true"
+
+	limit := defaultPageLimit
+	if val := query.Get("limit"); val != "" {
+		i, err := parseInt(val)
+		if err != nil || i < 1 {
+			respondError(w, http.StatusBadRequest, "invalid limit: "+val)
+			return
+		}
+		limit = min(i, maxPageLimit)
+	}
+
+	results := []SearchResult{}
+	for _, analysis := range h.store.GetAll(map[string]interface{}{}) {
+		if score := searchScore(analysis.Value, q, caseInsensitive); score > 0 {
+			results = append(results, SearchResult{StringAnalysis: analysis, Score: score})
+		}
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		if results[i].Properties.Length != results[j].Properties.Length {
+			return results[i].Properties.Length < results[j].Properties.Length
+		}
+		return results[i].Value < results[j].Value
+	})
+
+	if len(results) > limit {
+		results = results[:limit]
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"query": q,
+		"data":  results,
+		"count": len(results),
+	})
+}
+
+// GetStats returns aggregate statistics for the store. An empty store yields
+// zero values and an empty frequency map.
+func (h *StringHandler) GetStats(w http.ResponseWriter, r *http.Request) {
+	stats := Stats{CharacterFrequency: make(map[string]int)}
+	totalLength, totalWords := 0, 0
+
+	for _, analysis := range h.store.GetAll(map[string]interface{}{}) {
+		props := analysis.Properties
+
+		if stats.TotalCount == 0 || props.Length < stats.MinLength {
+			stats.MinLength = props.Length
+		}
+		if props.Length > stats.MaxLength {
+			stats.MaxLength = props.Length
+		}
+		if props.IsPalindrome {
+			stats.PalindromeCount++
+		}
+
+		totalLength += props.Length
+		totalWords += props.WordCount
+		stats.TotalCount++
+
+		for char, count := range props.CharacterFrequencyMap {
+			stats.CharacterFrequency[char] += count
+		}
+	}
+
+	if stats.TotalCount > 0 {
+		stats.AverageLength = float64(totalLength) / float64(stats.TotalCount)
+		stats.AverageWordCount = float64(totalWords) / float64(stats.TotalCount)
+	}
+
+	respondJSON(w, http.StatusOK, stats)
+}
+
+// Charset is the union of characters across every stored string.
+type Charset struct {
+	// Characters maps each character to its total occurrences. encoding/json
+	// writes map keys in byte order, which for UTF-8 is rune order.
+	Characters  map[string]int `json:"characters"`
+	UniqueCount int            `json:"unique_count"`
+	TotalCount  int            `json:"total_count"`
+}
 
-	if val, ok := filters["min_length"].(int); ok {
-		if analysis.Properties.Length < val {
-			return false
+// GetCharset merges the character frequency maps of every stored string.
+func (h *StringHandler) GetCharset(w http.ResponseWriter, r *http.Request) {
+	charset := Charset{Characters: make(map[string]int)}
+
+	for _, analysis := range h.store.GetAll(map[string]interface{}{}) {
+		for char, count := range analysis.Properties.CharacterFrequencyMap {
+			charset.Characters[char] += count
+			charset.TotalCount += count
 		}
 	}
+	charset.UniqueCount = len(charset.Characters)
 
-	if val, ok := filters["max_length"].(int); ok {
-		if analysis.Properties.Length > val {
-			return false
+	respondJSON(w, http.StatusOK, charset)
+}
+
+// FilterByNaturalLanguage interprets a plain-English query and returns the
+// matching strings. The query comes from the ?query= parameter, or for POST
+// from a {"query": "..."} body, which suits long queries better.
+func (h *StringHandler) FilterByNaturalLanguage(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query().Get("query")
+
+	if r.Method == http.MethodPost {
+		r.Body = http.MaxBytesReader(w, r.Body, h.maxBodyBytes())
+
+		var req struct {
+			Query string `json:"query"`
 		}
-	}
 
-	if val, ok := filters["word_count"].(int); ok {
-		if analysis.Properties.WordCount != val {
-			return false
+		// An empty body falls back to the URL parameter
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+			respondDecodeError(w, err)
+			return
+		}
+		if req.Query != "" {
+			query = req.Query
 		}
 	}
 
-	if val, ok := filters["contains_character"].(string); ok {
-		if !containsChar(analysis.Value, val) {
-			return false
-		}
+	if query == "" {
+		respondError(w, http.StatusBadRequest, "Missing 'query' parameter")
+		return
 	}
 
-	return true
+	h.respondNaturalLanguage(w, query)
 }
 
-func containsChar(s, char string) bool {
-	if len(char) == 0 {
-		return true
-	}
-	return strings.Contains(s, char)
-}
+// respondNaturalLanguage parses query and writes the matching strings along
+// with the interpretation, for both the GET and POST forms.
+func (h *StringHandler) respondNaturalLanguage(w http.ResponseWriter, query string) {
+	parsed := ParseNaturalLanguageQuery(query)
 
-// ===== HANDLERS =====
+	results := h.store.GetAll(parsed.Filters)
 
-type StringHandler struct {
-	store *MemoryStore
-}
+	response := map[string]interface{}{
+		"data":  results,
+		"count": len(results),
+		"interpreted_query": map[string]interface{}{
+			"original":       parsed.Original,
+			"parsed_filters": parsed.Filters,
+			"unrecognized":   parsed.Unrecognized,
+		},
+	}
 
-func NewStringHandler(store *MemoryStore) *StringHandler {
-	return &StringHandler{store: store}
+	respondJSON(w, http.StatusOK, response)
 }
 
-func (h *StringHandler) CreateString(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodPost {
-		respondError(w, http.StatusMethodNotAllowed, "Method not allowed")
+// UpdateString re-analyzes the stored value, or replaces it when the body
+// carries a new "value".
+func (h *StringHandler) UpdateString(w http.ResponseWriter, r *http.Request) {
+	value, err := pathValue(r, "/strings/", "")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid string value encoding")
 		return
 	}
 
+	palindromeMode, err := parsePalindromeMode(r.URL.Query())
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, h.maxBodyBytes())
+
 	var req struct {
 		Value string `json:"value"`
 	}
 
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		respondError(w, http.StatusBadRequest, "Invalid request body")
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		respondDecodeError(w, err)
 		return
 	}
 
-	if req.Value == "" {
-		respondError(w, http.StatusBadRequest, "Missing 'value' field")
-		return
+	newValue := req.Value
+	if newValue == "" {
+		newValue = value
 	}
 
-	analysis := NewStringAnalysis(req.Value)
-
-	if err := h.store.Create(analysis); err != nil {
-		respondError(w, http.StatusConflict, "String already exists")
+	if !h.checkLength(w, newValue) {
 		return
 	}
 
-	respondJSON(w, http.StatusCreated, analysis)
-}
+	// Without ?palindrome_mode= the entry keeps the mode it was stored with
+	if palindromeMode == "" {
+		if existing, err := h.store.Get(value); err == nil {
+			palindromeMode = existing.Properties.PalindromeMode
+		}
+	}
 
-func (h *StringHandler) GetString(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodGet {
-		respondError(w, http.StatusMethodNotAllowed, "Method not allowed")
+	analysis := NewStringAnalysis(newValue, AnalysisOptions{PalindromeMode: palindromeMode, HashAlgorithm: h.config.HashAlgorithm})
+
+	if err := h.store.Update(value, analysis); err != nil {
+		if errors.Is(err, ErrAlreadyExists) {
+			respondError(w, http.StatusConflict, "String already exists")
+			return
+		}
+		respondError(w, http.StatusNotFound, "String not found")
 		return
 	}
 
-	value := strings.TrimPrefix(r.URL.Path, "/strings/")
+	respondJSON(w, http.StatusOK, analysis)
+}
 
-	if value == "" || value == "strings" {
-		respondError(w, http.StatusBadRequest, "String value required")
+func (h *StringHandler) DeleteString(w http.ResponseWriter, r *http.Request) {
+	value, err := pathValue(r, "/strings/", "")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid string value encoding")
 		return
 	}
 
-	analysis, err := h.store.Get(value)
-	if err != nil {
+	if err := h.store.Delete(value); err != nil {
 		respondError(w, http.StatusNotFound, "String not found")
 		return
 	}
 
-	respondJSON(w, http.StatusOK, analysis)
+	h.metrics.StringsDeleted(1)
+	w.WriteHeader(http.StatusNoContent)
 }
 
-func (h *StringHandler) GetAllStrings(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodGet {
-		respondError(w, http.StatusMethodNotAllowed, "Method not allowed")
-		return
-	}
-
-	filters := make(map[string]interface{})
-	appliedFilters := make(map[string]interface{})
+// PurgeDeletedStrings hard-deletes every soft-deleted string.
+func (h *StringHandler) PurgeDeletedStrings(w http.ResponseWriter, r *http.Request) {
+	respondJSON(w, http.StatusOK, map[string]int{"purged": h.store.Purge()})
+}
 
+// DeleteAllStrings soft-deletes every string matching the GET /strings
+// filters, or wipes the store, tombstones included, given ?all=true instead.
+// It requires ?confirm=true so a stray DELETE on the collection cannot remove
+// anything by accident, and rejects unknown parameters so a mistyped filter
+// cannot widen the delete.
+func (h *StringHandler) DeleteAllStrings(w http.ResponseWriter, r *http.Request) {
 	query := r.URL.Query()
-
-	if val := query.Get("is_palindrome"); val != "" {
-		if val == "true" {
-			filters["is_palindrome"] = true
-			appliedFilters["is_palindrome"] = true
-		} else if val == "false" {
-			filters["is_palindrome"] = false
-			appliedFilters["is_palindrome"] = false
-		}
+	if query.Get("confirm") != "true" {
+		respondError(w, http.StatusBadRequest, "Deleting strings requires confirm=true")
+		return
 	}
 
-	if val := query.Get("min_length"); val != "" {
-		if i := parseInt(val); i > 0 {
-			filters["min_length"] = i
-			appliedFilters["min_length"] = i
+	for name := range query {
+		if !slices.Contains(filterParams, name) && name != "confirm" && name != "all" && name != "pretty" {
+			respondError(w, http.StatusBadRequest, "Unknown query parameter: "+name)
+			return
 		}
 	}
 
-	if val := query.Get("max_length"); val != "" {
-		if i := parseInt(val); i > 0 {
-			filters["max_length"] = i
-			appliedFilters["max_length"] = i
-		}
+	all, err := parseBoolParam(query, "all")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
 	}
 
-	if val := query.Get("word_count"); val != "" {
-		if i := parseInt(val); i >= 0 {
-			filters["word_count"] = i
-			appliedFilters["word_count"] = i
-		}
+	filters, applied, err := parseFilters(query)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
 	}
 
-	if val := query.Get("contains_character"); val != "" {
-		filters["contains_character"] = val
-		appliedFilters["contains_character"] = val
+	wipe := all != nil && *all
+	if wipe && len(filters) > 0 {
+		respondError(w, http.StatusBadRequest, "all=true cannot be combined with filters")
+		return
+	}
+	if !wipe && len(filters) == 0 {
+		respondError(w, http.StatusBadRequest, "Deleting every string requires all=true")
+		return
 	}
 
-	results := h.store.GetAll(filters)
+	if len(filters) > 0 {
+		deleted := 0
+		for _, analysis := range h.store.GetAll(filters) {
+			// A concurrent delete may already have removed the entry
+			if err := h.store.Delete(analysis.Value); err == nil {
+				deleted++
+			}
+		}
+		h.metrics.StringsDeleted(deleted)
 
-	response := map[string]interface{}{
-		"data":            results,
-		"count":           len(results),
-		"filters_applied": appliedFilters,
+		respondJSON(w, http.StatusOK, map[string]interface{}{
+			"deleted":         deleted,
+			"filters_applied": applied,
+		})
+		return
 	}
 
-	respondJSON(w, http.StatusOK, response)
+	count := h.store.Len()
+	h.store.Clear()
+	h.metrics.StringsDeleted(count)
+
+	w.WriteHeader(http.StatusNoContent)
 }
 
-func (h *StringHandler) FilterByNaturalLanguage(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodGet {
-		respondError(w, http.StatusMethodNotAllowed, "Method not allowed")
-		return
-	}
+// openAPISpec is the hand-written OpenAPI 3.0 description of the API. Keep it
+// in step with the routes and the Properties fields.
+//
+//go:embed openapi.json
+var openAPISpec []byte
 
-	query := r.URL.Query().Get("query")
-	if query == "" {
-		respondError(w, http.StatusBadRequest, "Missing 'query' parameter")
-		return
-	}
+func serveOpenAPI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(openAPISpec)
+}
 
-	parsed := ParseNaturalLanguageQuery(query)
+// pathValue extracts the segment between prefix and suffix from the escaped
+// request path and percent-decodes it, so values containing spaces, slashes
+// or other reserved characters round-trip intact.
+func pathValue(r *http.Request, prefix, suffix string) (string, error) {
+	raw := strings.TrimSuffix(strings.TrimPrefix(r.URL.EscapedPath(), prefix), suffix)
+	return url.PathUnescape(raw)
+}
 
-	results := h.store.GetAll(parsed.Filters)
+// respondJSON writes data as compact JSON, or indented by two spaces when
+// prettyJSONMiddleware marked the request with ?pretty=true.
+func respondJSON(w http.ResponseWriter, status int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
 
-	response := map[string]interface{}{
-		"data":  results,
-		"count": len(results),
-		"interpreted_query": map[string]interface{}{
-			"original":       parsed.Original,
-			"parsed_filters": parsed.Filters,
-		},
+	encoder := json.NewEncoder(w)
+	if _, ok := w.(*prettyJSONWriter); ok {
+		encoder.SetIndent("", "  ")
 	}
+	encoder.Encode(data)
+}
 
-	respondJSON(w, http.StatusOK, response)
+func respondError(w http.ResponseWriter, status int, message string) {
+	respondJSON(w, status, map[string]string{"error": message})
 }
 
-func (h *StringHandler) DeleteString(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodDelete {
-		respondError(w, http.StatusMethodNotAllowed, "Method not allowed")
-		return
-	}
+// respondNotFound is the JSON counterpart to http.NotFound for unknown routes.
+func respondNotFound(w http.ResponseWriter, r *http.Request) {
+	respondJSON(w, http.StatusNotFound, map[string]string{
+		"error": "not found",
+		"path":  r.URL.Path,
+	})
+}
 
-	value := strings.TrimPrefix(r.URL.Path, "/strings/")
+// sortFields maps each sort_by value to a comparison of two entries.
+var sortFields = map[string]func(a, b *StringAnalysis) int{
+	"length": func(a, b *StringAnalysis) int {
+		return a.Properties.Length - b.Properties.Length
+	},
+	"word_count": func(a, b *StringAnalysis) int {
+		return a.Properties.WordCount - b.Properties.WordCount
+	},
+	"unique_characters": func(a, b *StringAnalysis) int {
+		return a.Properties.UniqueCharacters - b.Properties.UniqueCharacters
+	},
+	"created_at": func(a, b *StringAnalysis) int {
+		return strings.Compare(a.CreatedAt, b.CreatedAt)
+	},
+	"updated_at": func(a, b *StringAnalysis) int {
+		return strings.Compare(a.UpdatedAt, b.UpdatedAt)
+	},
+	"value": func(a, b *StringAnalysis) int {
+		return strings.Compare(a.Value, b.Value)
+	},
+}
 
-	if err := h.store.Delete(value); err != nil {
-		respondError(w, http.StatusNotFound, "String not found")
-		return
+var sortFieldNames = []string{"length", "word_count", "unique_characters", "created_at", "updated_at", "value"}
+
+// sortResults orders results by the given field, breaking ties by value so
+// the order is stable across requests.
+func sortResults(results []*StringAnalysis, sortBy string, desc bool) {
+	cmp := sortFields[sortBy]
+	sort.Slice(results, func(i, j int) bool {
+		c := cmp(results[i], results[j])
+		if c == 0 {
+			return results[i].Value < results[j].Value
+		}
+		if desc {
+			return c > 0
+		}
+		return c < 0
+	})
+}
+
+const (
+	defaultPageLimit = 50
+	maxPageLimit     = 500
+	maxPatternLength = 1000
+)
+
+// parsePagination reads limit and offset from the query, applying the
+// defaults and capping limit at maxPageLimit.
+func parsePagination(query url.Values) (int, int, error) {
+	limit, offset := defaultPageLimit, 0
+
+	if val := query.Get("limit"); val != "" {
+		i, err := parseInt(val)
+		if err != nil || i < 0 {
+			return 0, 0, fmt.Errorf("invalid limit: %s", val)
+		}
+		limit = i
+	}
+	if limit > maxPageLimit {
+		limit = maxPageLimit
 	}
 
-	w.WriteHeader(http.StatusNoContent)
+	if val := query.Get("offset"); val != "" {
+		i, err := parseInt(val)
+		if err != nil || i < 0 {
+			return 0, 0, fmt.Errorf("invalid offset: %s", val)
+		}
+		offset = i
+	}
+
+	return limit, offset, nil
 }
 
-func respondJSON(w http.ResponseWriter, status int, data interface{}) {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(status)
-	json.NewEncoder(w).Encode(data)
+func paginate(results []*StringAnalysis, limit, offset int) []*StringAnalysis {
+	if offset >= len(results) {
+		return []*StringAnalysis{}
+	}
+	end := offset + limit
+	if end > len(results) {
+		end = len(results)
+	}
+	return results[offset:end]
 }
 
-func respondError(w http.ResponseWriter, status int, message string) {
-	respondJSON(w, status, map[string]string{"error": message})
+// respondDecodeError reports a JSON body decode failure, telling an empty body
+// apart from malformed JSON and from a field of the wrong type.
+func respondDecodeError(w http.ResponseWriter, err error) {
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	var maxBytesErr *http.MaxBytesError
+
+	switch {
+	case errors.As(err, &maxBytesErr):
+		respondError(w, http.StatusRequestEntityTooLarge, fmt.Sprintf("Request body exceeds %d bytes", maxBytesErr.Limit))
+	case errors.Is(err, io.EOF):
+		respondError(w, http.StatusBadRequest, "Request body is empty")
+	case errors.As(err, &syntaxErr):
+		respondError(w, http.StatusBadRequest, fmt.Sprintf("Malformed JSON at offset %d: %s", syntaxErr.Offset, syntaxErr.Error()))
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		respondError(w, http.StatusBadRequest, "Malformed JSON: unexpected end of input")
+	case errors.As(err, &typeErr):
+		respondError(w, http.StatusUnprocessableEntity, fmt.Sprintf("Invalid type for '%s' field", typeErr.Field))
+	default:
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+	}
 }
 
-func parseInt(s string) int {
-	var i int
-	fmt.Sscanf(s, "%d", &i)
-	return i
+func parseInt(s string) (int, error) {
+	return strconv.Atoi(strings.TrimSpace(s))
 }
 
 // ===== NATURAL LANGUAGE PARSER =====
@@ -475,23 +4885,40 @@ func parseInt(s string) int {
 type ParsedQuery struct {
 	Original string                 `json:"original"`
 	Filters  map[string]interface{} `json:"parsed_filters"`
+	// Unrecognized lists the runs of consecutive words that matched no
+	// filter, so clients can tell when part of a query was ignored.
+	Unrecognized []string `json:"unrecognized"`
 }
 
 func ParseNaturalLanguageQuery(query string) *ParsedQuery {
 	query = strings.ToLower(strings.TrimSpace(query))
 	filters := make(map[string]interface{})
 
-	// Check for palindrome keywords
-	if containsAny(query, []string{"palindrome", "palindromic", "reads same"}) {
-		filters["is_palindrome"] = true
+	// Check for palindrome keywords, honouring negation ("not palindromes")
+	if val, ok := parsePalindromeClause(query); ok {
+		filters["is_palindrome"] = val
 	}
 
-	// Check for word count
-	if strings.Contains(query, "single word") {
+	// Check for word-count ranges: "more than 3 words", "fewer than five words"
+	wordRange := false
+	if num, ok := parseCountClause(query, []string{"more than"}, "word"); ok {
+		filters["min_word_count"] = num + 1
+		wordRange = true
+	}
+	if num, ok := parseCountClause(query, []string{"fewer than", "less than"}, "word"); ok && num > 0 {
+		filters["max_word_count"] = num - 1
+		wordRange = true
+	}
+
+	// Check for an exact word count. Skipped after a range so "more than 3
+	// words" does not also read as "3 word".
+	switch {
+	case wordRange:
+	case strings.Contains(query, "single word"):
 		filters["word_count"] = 1
-	} else if strings.Contains(query, "two word") || strings.Contains(query, "2 word") {
+	case strings.Contains(query, "two word") || strings.Contains(query, "2 word"):
 		filters["word_count"] = 2
-	} else if strings.Contains(query, "three word") || strings.Contains(query, "3 word") {
+	case strings.Contains(query, "three word") || strings.Contains(query, "3 word"):
 		filters["word_count"] = 3
 	}
 
@@ -502,7 +4929,7 @@ func ParseNaturalLanguageQuery(query string) *ParsedQuery {
 		if len(parts) > 1 {
 			words := strings.Fields(parts[1])
 			if len(words) > 0 {
-				if num := parseInt(words[0]); num > 0 {
+				if num, ok := wordToNumber(words[0]); ok && num > 0 {
 					filters["min_length"] = num + 1
 				}
 			}
@@ -514,7 +4941,7 @@ func ParseNaturalLanguageQuery(query string) *ParsedQuery {
 		if len(parts) > 1 {
 			words := strings.Fields(parts[1])
 			if len(words) > 0 {
-				if num := parseInt(words[0]); num > 0 {
+				if num, ok := wordToNumber(words[0]); ok && num > 0 {
 					filters["max_length"] = num - 1
 				}
 			}
@@ -526,8 +4953,36 @@ func ParseNaturalLanguageQuery(query string) *ParsedQuery {
 		if len(parts) > 1 {
 			words := strings.Fields(parts[1])
 			if len(words) > 0 {
-				if num := parseInt(words[0]); num > 0 {
+				if num, ok := wordToNumber(words[0]); ok && num > 0 {
+					filters["min_length"] = num
+				}
+			}
+		}
+	}
+
+	// Check for length ranges: "between X and Y", "exactly N"
+	if strings.Contains(query, "between") {
+		parts := strings.SplitN(query, "between", 2)
+		if nums := extractNumbers(parts[1], 2); len(nums) == 2 {
+			low, high := nums[0], nums[1]
+			if low > high {
+				low, high = high, low
+			}
+			filters["min_length"] = low
+			filters["max_length"] = high
+		}
+	}
+
+	if strings.Contains(query, "exactly") {
+		parts := strings.SplitN(query, "exactly", 2)
+		words := strings.Fields(parts[1])
+		if len(words) > 0 {
+			if num, ok := wordToNumber(words[0]); ok {
+				if len(words) > 1 && strings.HasPrefix(words[1], "word") {
+					filters["word_count"] = num
+				} else {
 					filters["min_length"] = num
+					filters["max_length"] = num
 				}
 			}
 		}
@@ -555,15 +5010,244 @@ func ParseNaturalLanguageQuery(query string) *ParsedQuery {
 		}
 	}
 
+	// Check for substring containment: "containing the word cat"
+	if idx := strings.Index(query, "contain"); idx >= 0 {
+		rest := query[idx:]
+		if i := strings.Index(rest, "word "); i >= 0 {
+			words := strings.Fields(rest[i+len("word "):])
+			if len(words) > 0 {
+				filters["contains_substring"] = strings.Trim(words[0], "\"'.,;")
+				// The query has been lowercased, so match regardless of case
+				filters["case_insensitive"] = true
+			}
+		}
+	}
+
+	// Check for affixes: "starting with a", "ending with \"ing\""
+	if val, ok := parseAffixClause(query, []string{"starting with", "starts with", "beginning with", "begins with"}); ok {
+		filters["starts_with"] = val
+	}
+	if val, ok := parseAffixClause(query, []string{"ending with", "ends with"}); ok {
+		filters["ends_with"] = val
+	}
+
 	// Special case: "first vowel" = 'a'
 	if strings.Contains(query, "first vowel") {
 		filters["contains_character"] = "a"
 	}
 
 	return &ParsedQuery{
-		Original: query,
-		Filters:  filters,
+		Original:     query,
+		Filters:      filters,
+		Unrecognized: unrecognizedPhrases(query, filters),
+	}
+}
+
+// unrecognizedPhrases returns the runs of consecutive words in query that are
+// neither parser vocabulary, numbers, nor part of a parsed filter value.
+func unrecognizedPhrases(query string, filters map[string]interface{}) []string {
+	values := make(map[string]bool)
+	for _, key := range []string{"contains_character", "contains_substring", "starts_with", "ends_with"} {
+		if val, ok := filters[key].(string); ok {
+			for _, word := range strings.Fields(val) {
+				values[word] = true
+			}
+		}
+	}
+
+	phrases := []string{}
+	var run []string
+	flush := func() {
+		if len(run) > 0 {
+			phrases = append(phrases, strings.Join(run, " "))
+			run = nil
+		}
+	}
+
+	for _, word := range strings.Fields(query) {
+		word = strings.Trim(word, ",.;:!?\"'")
+		_, isNumber := wordToNumber(word)
+		known := word == "" || isNumber || values[word] || nlVocabulary[word] ||
+			negationWords[word] || strings.Contains(word, "palindrom")
+		if known {
+			flush()
+			continue
+		}
+		run = append(run, word)
+	}
+	flush()
+
+	return phrases
+}
+
+// nlVocabulary holds the keywords ParseNaturalLanguageQuery understands plus
+// common filler, so neither is reported as unrecognized.
+var nlVocabulary = map[string]bool{
+	// Clause keywords
+	"single": true, "word": true, "words": true, "longer": true, "shorter": true,
+	"than": true, "more": true, "fewer": true, "less": true, "at": true,
+	"least": true, "between": true, "and": true, "exactly": true,
+	"character": true, "characters": true, "letter": true, "letters": true,
+	"contain": true, "contains": true, "containing": true, "with": true,
+	"starting": true, "starts": true, "beginning": true, "begins": true,
+	"ending": true, "ends": true, "first": true, "vowel": true,
+	"reads": true, "same": true,
+	// Filler
+	"a": true, "an": true, "the": true, "all": true, "string": true,
+	"strings": true, "sentence": true, "sentences": true, "that": true,
+	"which": true, "are": true, "is": true, "have": true, "has": true,
+	"of": true, "long": true, "show": true, "me": true, "find": true,
+	"get": true, "list": true,
+}
+
+// parsePalindromeClause reports whether the query asks for palindromes and,
+// if so, whether it is negated by a "non-" prefix or a "not" shortly before
+// the keyword.
+func parsePalindromeClause(query string) (bool, bool) {
+	words := strings.Fields(query)
+	for i, word := range words {
+		if !strings.Contains(word, "palindrom") {
+			continue
+		}
+
+		if strings.HasPrefix(word, "non") {
+			return false, true
+		}
+
+		for j := i - 1; j >= 0 && j >= i-2; j-- {
+			if negationWords[words[j]] {
+				return false, true
+			}
+		}
+
+		return true, true
+	}
+
+	if containsAny(query, []string{"reads same", "reads the same"}) {
+		return true, true
+	}
+
+	return false, false
+}
+
+// parseCountClause finds one of phrases followed by a number and a word
+// starting with unit, as in "more than 3 words", and returns the number.
+func parseCountClause(query string, phrases []string, unit string) (int, bool) {
+	for _, phrase := range phrases {
+		for _, part := range strings.Split(query, phrase)[1:] {
+			words := strings.Fields(part)
+			if len(words) < 2 || !strings.HasPrefix(words[1], unit) {
+				continue
+			}
+			if num, ok := wordToNumber(words[0]); ok {
+				return num, true
+			}
+		}
+	}
+
+	return 0, false
+}
+
+// parseAffixClause finds the first of phrases in the query and returns the
+// prefix or suffix that follows it. A quoted value ("re" or 're') is taken
+// verbatim; otherwise the next word is used, skipping filler such as
+// "the letter".
+func parseAffixClause(query string, phrases []string) (string, bool) {
+	for _, phrase := range phrases {
+		idx := strings.Index(query, phrase)
+		if idx < 0 {
+			continue
+		}
+		rest := strings.TrimSpace(query[idx+len(phrase):])
+
+		if rest != "" && (rest[0] == '"' || rest[0] == '\'') {
+			if end := strings.IndexByte(rest[1:], rest[0]); end > 0 {
+				return rest[1 : end+1], true
+			}
+		}
+
+		for _, word := range strings.Fields(rest) {
+			if affixFillerWords[word] {
+				continue
+			}
+			if word = strings.Trim(word, "\"'.,;"); word != "" {
+				return word, true
+			}
+			break
+		}
+	}
+
+	return "", false
+}
+
+var affixFillerWords = map[string]bool{
+	"the":       true,
+	"letter":    true,
+	"letters":   true,
+	"character": true,
+}
+
+var negationWords = map[string]bool{
+	"not":    true,
+	"non":    true,
+	"no":     true,
+	"isn't":  true,
+	"aren't": true,
+}
+
+// extractNumbers returns up to n integers found among the words of s, in
+// order, skipping any non-numeric words in between.
+func extractNumbers(s string, n int) []int {
+	var nums []int
+	for _, word := range strings.Fields(s) {
+		if num, ok := wordToNumber(word); ok {
+			nums = append(nums, num)
+			if len(nums) == n {
+				break
+			}
+		}
+	}
+	return nums
+}
+
+// numberWords maps spelled-out English numbers to their values. Compounds
+// such as "twenty-one" are handled by wordToNumber.
+var numberWords = map[string]int{
+	"zero": 0, "one": 1, "two": 2, "three": 3, "four": 4, "five": 5,
+	"six": 6, "seven": 7, "eight": 8, "nine": 9, "ten": 10,
+	"eleven": 11, "twelve": 12, "thirteen": 13, "fourteen": 14, "fifteen": 15,
+	"sixteen": 16, "seventeen": 17, "eighteen": 18, "nineteen": 19, "twenty": 20,
+	"thirty": 30, "forty": 40, "fifty": 50, "sixty": 60,
+	"seventy": 70, "eighty": 80, "ninety": 90,
+}
+
+// wordToNumber parses a non-negative number written as digits ("5"), a word
+// ("five") or a hyphenated tens compound ("twenty-five"), ignoring
+// surrounding punctuation.
+func wordToNumber(word string) (int, bool) {
+	word = strings.ToLower(strings.Trim(word, ",.;:!?\"'"))
+
+	if num, err := parseInt(word); err == nil {
+		return num, num >= 0
+	}
+
+	if num, ok := numberWords[word]; ok {
+		return num, true
+	}
+
+	tens, unit, found := strings.Cut(word, "-")
+	if !found {
+		return 0, false
+	}
+	t, ok := numberWords[tens]
+	if !ok || t < 20 || t%10 != 0 {
+		return 0, false
+	}
+	u, ok := numberWords[unit]
+	if !ok || u < 1 || u > 9 {
+		return 0, false
 	}
+	return t + u, true
 }
 
 func containsAny(s string, substrs []string) bool {
@@ -0,0 +1,447 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+// TestMemoryStoreConcurrentAccess hammers Create and GetAll from many
+// goroutines at once. Run it with -race to check the store's locking.
+func TestMemoryStoreConcurrentAccess(t *testing.T) {
+	store := NewMemoryStore(0)
+
+	const goroutines = 50
+	const perGoroutine = 20
+
+	var wg sync.WaitGroup
+	for g := range goroutines {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for i := range perGoroutine {
+				value := fmt.Sprintf("value-%d-%d", g, i)
+				if err := store.Create(NewStringAnalysis(value, AnalysisOptions{})); err != nil {
+					t.Errorf("Create(%q): %v", value, err)
+				}
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for range perGoroutine {
+				store.GetAll(map[string]interface{}{})
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got, want := len(store.GetAll(map[string]interface{}{})), goroutines*perGoroutine; got != want {
+		t.Errorf("GetAll returned %d entries, want %d", got, want)
+	}
+}
+
+func TestCharClassCounts(t *testing.T) {
+	tests := []struct {
+		input                                  string
+		vowels, consonants, digits, whitespace int
+	}{
+		{"", 0, 0, 0, 0},
+		{"hello", 2, 3, 0, 0},
+		{"AEIOU aeiou", 10, 0, 0, 1},
+		{"Route 66\tnorth\n", 4, 6, 2, 3},
+		{"rhythm", 0, 6, 0, 0},
+		{"12345", 0, 0, 5, 0},
+		{"Hi, there!", 3, 4, 0, 1},
+	}
+
+	for _, tt := range tests {
+		props := NewStringAnalysis(tt.input, AnalysisOptions{}).Properties
+		got := [4]int{props.VowelCount, props.ConsonantCount, props.DigitCount, props.WhitespaceCount}
+		want := [4]int{tt.vowels, tt.consonants, tt.digits, tt.whitespace}
+		if got != want {
+			t.Errorf("%q: vowels, consonants, digits, whitespace = %v, want %v", tt.input, got, want)
+		}
+	}
+}
+
+func TestEntropy(t *testing.T) {
+	tests := []struct {
+		input string
+		want  float64
+	}{
+		{"", 0},
+		{"aaaa", 0},
+		{"ab", 1},
+		{"abcd", 2},
+		{"aabb", 1},
+		{"aab", 0.9183},
+		{"abcde", 2.3219},
+		{"😀😀🎉🎉", 1},
+	}
+
+	for _, tt := range tests {
+		if got := NewStringAnalysis(tt.input, AnalysisOptions{}).Properties.Entropy; got != tt.want {
+			t.Errorf("%q: entropy = %v, want %v", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestIsPalindrome(t *testing.T) {
+	tests := []struct {
+		input string
+		mode  string
+		want  bool
+	}{
+		{"", palindromeAlphanumeric, true},
+		{"racecar", palindromeAlphanumeric, true},
+		{"hello", palindromeAlphanumeric, false},
+		// Multi-byte runes compare whole, not byte by byte
+		{"été", palindromeStrict, true},
+		{"étè", palindromeStrict, false},
+		{"😀🎉😀", palindromeStrict, true},
+		{"😀🎉🙂", palindromeStrict, false},
+		{"Été", palindromeAlphanumeric, true},
+		// Phrase palindromes only match once spaces and punctuation are dropped
+		{"A man, a plan, a canal: Panama", palindromeAlphanumeric, true},
+		{"A man, a plan, a canal: Panama", palindromeCaseInsensitive, false},
+		{"Was it a car or a cat I saw?", palindromeAlphanumeric, true},
+		{"Racecar", palindromeCaseInsensitive, true},
+		{"Racecar", palindromeStrict, false},
+	}
+
+	for _, tt := range tests {
+		if got := isPalindrome(tt.input, tt.mode); got != tt.want {
+			t.Errorf("isPalindrome(%q, %q) = %v, want %v", tt.input, tt.mode, got, tt.want)
+		}
+	}
+}
+
+func TestReverseString(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"", ""},
+		{"hello", "olleh"},
+		{"héllo", "olléh"},
+		{"a😀b", "b😀a"},
+		{"\U0001F44D\U0001F3FD!", "!\U0001F3FD\U0001F44D"},
+		// Reversing by rune moves the combining acute accent (U+0301) with
+		// the rune order, but never splits its bytes
+		{"e\u0301x", "x\u0301e"},
+	}
+
+	for _, tt := range tests {
+		got := reverseString(tt.input)
+		if got != tt.want {
+			t.Errorf("reverseString(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+		if !utf8.ValidString(got) {
+			t.Errorf("reverseString(%q) = %q is not valid UTF-8", tt.input, got)
+		}
+		if back := reverseString(got); back != tt.input {
+			t.Errorf("reverseString(reverseString(%q)) = %q", tt.input, back)
+		}
+	}
+}
+
+func TestLongestAndShortestWords(t *testing.T) {
+	tests := []struct {
+		input             string
+		longest, shortest string
+	}{
+		{"", "", ""},
+		{"   ", "", ""},
+		{"hello", "hello", "hello"},
+		{"  hello  ", "hello", "hello"},
+		{"the   quick\tbrown  fox", "quick", "the"},
+		// Ties go to the first occurrence
+		{"cat dog emu ant", "cat", "cat"},
+		// Lengths are counted in runes, not bytes
+		{"ééé abcd", "abcd", "ééé"},
+	}
+
+	for _, tt := range tests {
+		longest, shortest := longestAndShortestWords(tt.input)
+		if longest != tt.longest || shortest != tt.shortest {
+			t.Errorf("longestAndShortestWords(%q) = %q, %q, want %q, %q",
+				tt.input, longest, shortest, tt.longest, tt.shortest)
+		}
+	}
+}
+
+func TestLineAndSentenceCounts(t *testing.T) {
+	tests := []struct {
+		input            string
+		lines, sentences int
+	}{
+		{"", 0, 0},
+		{"hello", 1, 1},
+		{"hello\n", 1, 1},
+		{"one\ntwo\n", 2, 1},
+		{"one\n\nthree", 3, 1},
+		{"\n", 1, 0},
+		{"Wow!! Really?", 1, 2},
+		{"Hi. Bye.", 1, 2},
+		{"no terminator here", 1, 1},
+		{"!!", 1, 0},
+		{"...", 1, 0},
+		{"Done. and more", 1, 2},
+	}
+
+	for _, tt := range tests {
+		if got := countLines(tt.input); got != tt.lines {
+			t.Errorf("countLines(%q) = %d, want %d", tt.input, got, tt.lines)
+		}
+		if got := countSentences(tt.input); got != tt.sentences {
+			t.Errorf("countSentences(%q) = %d, want %d", tt.input, got, tt.sentences)
+		}
+	}
+}
+
+func TestIsPangram(t *testing.T) {
+	tests := []struct {
+		input string
+		want  bool
+	}{
+		{"", false},
+		{"The quick brown fox jumps over the lazy dog", true},
+		{"THE QUICK BROWN FOX JUMPS OVER THE LAZY DOG", true},
+		{"Pack my box with five dozen liquor jugs.", true},
+		// Missing the "s" of "jumps"
+		{"The quick brown fox jump over the lazy dog", false},
+		{"abcdefghijklmnopqrstuvwxy", false},
+	}
+
+	for _, tt := range tests {
+		if got := isPangram(tt.input); got != tt.want {
+			t.Errorf("isPangram(%q) = %v, want %v", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestCaseCounts(t *testing.T) {
+	tests := []struct {
+		input        string
+		upper, lower int
+	}{
+		{"", 0, 0},
+		{"Hello World", 2, 8},
+		{"SHOUTING", 8, 0},
+		{"quiet", 0, 5},
+		// Digits and punctuation count as neither
+		{"1234 !?.,", 0, 0},
+		{"Pa55w0rd!", 1, 4},
+		{"ÉCOLE élève", 5, 5},
+	}
+
+	for _, tt := range tests {
+		props := NewStringAnalysis(tt.input, AnalysisOptions{}).Properties
+		if props.UppercaseCount != tt.upper || props.LowercaseCount != tt.lower {
+			t.Errorf("%q: uppercase, lowercase = %d, %d, want %d, %d",
+				tt.input, props.UppercaseCount, props.LowercaseCount, tt.upper, tt.lower)
+		}
+	}
+}
+
+func TestHasUnpairedSurrogate(t *testing.T) {
+	tests := []struct {
+		input string
+		want  bool
+	}{
+		{`{"value": "hello"}`, false},
+		{`{"value": "\u00e9"}`, false},
+		{`{"value": "\ud83d\ude00"}`, false},
+		{`{"value": "\uD83D\uDE00"}`, false},
+		{`{"value": "\ud800"}`, true},
+		{`{"value": "a\udc00b"}`, true},
+		{`{"value": "\ud800\u0041"}`, true},
+		{`{"value": "\ud800\ud800\udc00"}`, true},
+		// An escaped backslash followed by "ud800" is plain text
+		{`{"value": "\\ud800"}`, false},
+	}
+
+	for _, tt := range tests {
+		if got := hasUnpairedSurrogate([]byte(tt.input)); got != tt.want {
+			t.Errorf("hasUnpairedSurrogate(%s) = %v, want %v", tt.input, got, tt.want)
+		}
+	}
+}
+
+// TestCreateStringInvalidUTF8 posts bodies that encoding/json would quietly
+// repair, and checks they are rejected unless sanitize=true.
+func TestCreateStringInvalidUTF8(t *testing.T) {
+	tests := []struct {
+		name   string
+		body   string
+		query  string
+		status int
+		value  string
+	}{
+		{"raw invalid byte", "{\"value\": \"ab\xffcd\"}", "", http.StatusBadRequest, ""},
+		{"lone surrogate escape", `{"value": "x\ud800y"}`, "", http.StatusBadRequest, ""},
+		{"raw invalid byte sanitized", "{\"value\": \"ab\xffcd\"}", "?sanitize=true", http.StatusCreated, "ab\uFFFDcd"},
+		{"lone surrogate sanitized", `{"value": "x\ud800y"}`, "?sanitize=true", http.StatusCreated, "x\uFFFDy"},
+		{"surrogate pair", `{"value": "\ud83d\ude00"}`, "", http.StatusCreated, "\U0001F600"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := NewStringHandler(NewMemoryStore(0), NewMetrics(), Config{MaxStringLength: defaultMaxStringLength})
+			req := httptest.NewRequest(http.MethodPost, "/strings"+tt.query, strings.NewReader(tt.body))
+			rec := httptest.NewRecorder()
+			handler.CreateString(rec, req)
+
+			if rec.Code != tt.status {
+				t.Fatalf("status = %d, want %d (body %s)", rec.Code, tt.status, rec.Body)
+			}
+			if tt.status != http.StatusCreated {
+				return
+			}
+			var got StringAnalysis
+			if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+				t.Fatalf("decoding response: %v", err)
+			}
+			if got.Value != tt.value {
+				t.Errorf("value = %q, want %q", got.Value, tt.value)
+			}
+		})
+	}
+}
+
+// TestCurrentTimeOrdering checks that timestamps taken back to back sort as
+// strings in the order they were taken and still parse as RFC3339.
+func TestCurrentTimeOrdering(t *testing.T) {
+	prev := getCurrentTime()
+	for range 1000 {
+		curr := getCurrentTime()
+		if curr < prev {
+			t.Fatalf("getCurrentTime() = %q sorts before the earlier %q", curr, prev)
+		}
+		if _, err := time.Parse(time.RFC3339, curr); err != nil {
+			t.Fatalf("getCurrentTime() = %q does not parse as RFC3339: %v", curr, err)
+		}
+		prev = curr
+	}
+}
+
+// TestGetStringETagChanges checks that a cached ETag stops matching once the
+// entry is re-analyzed, or deleted and created again.
+func TestGetStringETagChanges(t *testing.T) {
+	handler := NewStringHandler(NewMemoryStore(0), NewMetrics(), Config{MaxStringLength: defaultMaxStringLength})
+
+	do := func(method, target, ifNoneMatch string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, nil)
+		if ifNoneMatch != "" {
+			req.Header.Set("If-None-Match", ifNoneMatch)
+		}
+		rec := httptest.NewRecorder()
+		switch method {
+		case http.MethodPost:
+			req.Body = io.NopCloser(strings.NewReader(`{"value": "Abba"}`))
+			handler.CreateString(rec, req)
+		case http.MethodPut:
+			handler.UpdateString(rec, req)
+		case http.MethodDelete:
+			handler.DeleteString(rec, req)
+		default:
+			handler.GetString(rec, req)
+		}
+		return rec
+	}
+
+	do(http.MethodPost, "/strings", "")
+	etag := do(http.MethodGet, "/strings/Abba", "").Header().Get("ETag")
+	if got := do(http.MethodGet, "/strings/Abba", etag).Code; got != http.StatusNotModified {
+		t.Fatalf("unchanged entry: status = %d, want %d", got, http.StatusNotModified)
+	}
+
+	do(http.MethodPut, "/strings/Abba?palindrome_mode=strict", "")
+	if got := do(http.MethodGet, "/strings/Abba", etag).Code; got != http.StatusOK {
+		t.Errorf("after update: status = %d, want %d", got, http.StatusOK)
+	}
+
+	etag = do(http.MethodGet, "/strings/Abba", "").Header().Get("ETag")
+	do(http.MethodDelete, "/strings/Abba", "")
+	do(http.MethodPost, "/strings", "")
+	if got := do(http.MethodGet, "/strings/Abba", etag).Code; got != http.StatusOK {
+		t.Errorf("after delete and re-create: status = %d, want %d", got, http.StatusOK)
+	}
+}
+
+func TestEntityTagIncludesPalindromeMode(t *testing.T) {
+	strict := NewStringAnalysis("Abba", AnalysisOptions{PalindromeMode: palindromeStrict})
+	folded := *strict
+	folded.Properties.PalindromeMode = palindromeCaseInsensitive
+	folded.Properties.IsPalindrome = true
+
+	if entityTag(strict) == entityTag(&folded) {
+		t.Errorf("entries stored under different palindrome modes share the ETag %s", entityTag(strict))
+	}
+}
+
+// TestGetSimilarStringsMaxDistance checks that skipping candidates by length
+// difference keeps those exactly at max_distance.
+func TestGetSimilarStringsMaxDistance(t *testing.T) {
+	store := NewMemoryStore(0)
+	for _, value := range []string{"mitten", "sitting", "kit", "kittenish", "dog"} {
+		store.Create(NewStringAnalysis(value, AnalysisOptions{}))
+	}
+	handler := NewStringHandler(store, NewMetrics(), Config{MaxStringLength: defaultMaxStringLength})
+
+	rec := httptest.NewRecorder()
+	handler.GetSimilarStrings(rec, httptest.NewRequest(http.MethodGet, "/strings/kitten/similar?max_distance=3", nil))
+
+	var got struct {
+		Data []SimilarString `json:"data"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+		t.Fatalf("decoding response: %v", err)
+	}
+
+	want := []SimilarString{{Value: "mitten", Distance: 1}, {Value: "kit", Distance: 3}, {Value: "kittenish", Distance: 3}, {Value: "sitting", Distance: 3}}
+	if len(got.Data) != len(want) {
+		t.Fatalf("got %d matches %v, want %v", len(got.Data), got.Data, want)
+	}
+	for i := range want {
+		if got.Data[i].Value != want[i].Value || got.Data[i].Distance != want[i].Distance {
+			t.Errorf("match %d = %s at %d, want %s at %d",
+				i, got.Data[i].Value, got.Data[i].Distance, want[i].Value, want[i].Distance)
+		}
+	}
+}
+
+func TestPalindromicSubstrings(t *testing.T) {
+	tests := []struct {
+		input     string
+		minLength int
+		want      []string
+	}{
+		{"", 2, nil},
+		{"wordplay", 2, nil},
+		{"racecar", 2, []string{"racecar", "aceca", "cec"}},
+		{"racecar", 6, []string{"racecar"}},
+		{"aaaa", 2, []string{"aa", "aaa", "aaaa"}},
+		{"aaaa", 4, []string{"aaaa"}},
+		// Repeats are listed once, at their first occurrence
+		{"abaxaba", 3, []string{"aba", "abaxaba", "baxab", "axa"}},
+		{"éxé", 1, []string{"é", "éxé", "x"}},
+	}
+
+	for _, tt := range tests {
+		var got []string
+		for _, p := range palindromicSubstrings(tt.input, tt.minLength) {
+			got = append(got, p.Substring)
+		}
+		if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+			t.Errorf("palindromicSubstrings(%q, %d) = %v, want %v", tt.input, tt.minLength, got, tt.want)
+		}
+	}
+}
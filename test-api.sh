@@ -16,11 +16,13 @@ echo ""
 GREEN='\033[0;32m'
 RED='\033[0;31m'
 BLUE='\033[0;34m'
+YELLOW='\033[0;33m'
 NC='\033[0m' # No Color
 
 test_count=0
 pass_count=0
 fail_count=0
+skip_count=0
 
 # Function to test endpoint
 test_endpoint() {
@@ -30,18 +32,27 @@ test_endpoint() {
     local endpoint=$3
     local data=$4
     local expected_status=$5
+    local extra_header=$6
     
     echo -e "${BLUE}Test $test_count: $description${NC}"
     echo "  Method: $method"
     echo "  Endpoint: $endpoint"
+
+    local header_args=()
+    if [ -n "$extra_header" ]; then
+        echo "  Header: $extra_header"
+        header_args=(-H "$extra_header")
+    fi
     
     if [ -n "$data" ]; then
         echo "  Data: $data"
         response=$(curl -s -w "\n%{http_code}" -X "$method" "$BASE_URL$endpoint" \
             -H "Content-Type: application/json" \
+            "${header_args[@]}" \
             -d "$data")
     else
-        response=$(curl -s -w "\n%{http_code}" -X "$method" "$BASE_URL$endpoint")
+        response=$(curl -s -w "\n%{http_code}" -X "$method" "$BASE_URL$endpoint" \
+            "${header_args[@]}")
     fi
     
     http_code=$(echo "$response" | tail -n1)
@@ -59,6 +70,245 @@ test_endpoint() {
     echo ""
 }
 
+# Function to test an endpoint's status and check that the response body
+# contains each of the given fragments verbatim
+test_response_contains() {
+    test_count=$((test_count + 1))
+    local description=$1
+    local method=$2
+    local endpoint=$3
+    local data=$4
+    local expected_status=$5
+    shift 5
+
+    echo -e "${BLUE}Test $test_count: $description${NC}"
+    echo "  Method: $method"
+    echo "  Endpoint: $endpoint"
+    echo "  Data: $data"
+
+    response=$(curl -s -w "\n%{http_code}" -X "$method" "$BASE_URL$endpoint" \
+        -H "Content-Type: application/json" \
+        -d "$data")
+    http_code=$(echo "$response" | tail -n1)
+    body=$(echo "$response" | head -n-1)
+
+    local missing=""
+    for fragment in "$@"; do
+        if ! echo "$body" | grep -qF "$fragment"; then
+            missing="$missing $fragment"
+        fi
+    done
+
+    if [ "$http_code" == "$expected_status" ] && [ -z "$missing" ]; then
+        echo -e "  ${GREEN}✓ PASS${NC} (Status: $http_code)"
+        pass_count=$((pass_count + 1))
+    else
+        echo -e "  ${RED}✗ FAIL${NC} (Expected: $expected_status, Got: $http_code, Missing:${missing:- none})"
+        fail_count=$((fail_count + 1))
+    fi
+
+    echo "  Response: $body"
+    echo ""
+}
+
+# Function to check that a GET response body contains each JSON field
+test_json_fields() {
+    test_count=$((test_count + 1))
+    local description=$1
+    local endpoint=$2
+    shift 2
+
+    echo -e "${BLUE}Test $test_count: $description${NC}"
+    echo "  Method: GET"
+    echo "  Endpoint: $endpoint"
+
+    body=$(curl -s "$BASE_URL$endpoint")
+    local missing=""
+    for field in "$@"; do
+        if ! echo "$body" | grep -q "\"$field\":"; then
+            missing="$missing $field"
+        fi
+    done
+
+    if [ -z "$missing" ]; then
+        echo -e "  ${GREEN}✓ PASS${NC} (Fields: $*)"
+        pass_count=$((pass_count + 1))
+    else
+        echo -e "  ${RED}✗ FAIL${NC} (Missing fields:$missing)"
+        fail_count=$((fail_count + 1))
+    fi
+
+    echo "  Response: $body"
+    echo ""
+}
+
+# Function to check the X-Request-ID echoed on a GET. With no request ID,
+# any generated UUID passes.
+test_request_id() {
+    test_count=$((test_count + 1))
+    local description=$1
+    local endpoint=$2
+    local request_id=$3
+
+    echo -e "${BLUE}Test $test_count: $description${NC}"
+    echo "  Method: GET"
+    echo "  Endpoint: $endpoint"
+
+    local header_args=()
+    if [ -n "$request_id" ]; then
+        echo "  Header: X-Request-ID: $request_id"
+        header_args=(-H "X-Request-ID: $request_id")
+    fi
+
+    value=$(curl -s -D - -o /dev/null "${header_args[@]}" "$BASE_URL$endpoint" \
+        | grep -i "^X-Request-ID:" | cut -d' ' -f2- | tr -d '\r')
+
+    if { [ -n "$request_id" ] && [ "$value" == "$request_id" ]; } || \
+        { [ -z "$request_id" ] && [[ "$value" =~ ^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$ ]]; }; then
+        echo -e "  ${GREEN}✓ PASS${NC} (X-Request-ID: $value)"
+        pass_count=$((pass_count + 1))
+    else
+        echo -e "  ${RED}✗ FAIL${NC} (Expected: ${request_id:-generated UUID}, Got: $value)"
+        fail_count=$((fail_count + 1))
+    fi
+    echo ""
+}
+
+# Function to check a GET response is gzip-encoded and decompresses to the
+# uncompressed body
+test_gzip() {
+    test_count=$((test_count + 1))
+    local description=$1
+    local endpoint=$2
+
+    echo -e "${BLUE}Test $test_count: $description${NC}"
+    echo "  Method: GET"
+    echo "  Endpoint: $endpoint"
+
+    encoding=$(curl -s -D - -o /dev/null -H "Accept-Encoding: gzip" "$BASE_URL$endpoint" \
+        | grep -i "^Content-Encoding:" | cut -d' ' -f2- | tr -d '\r')
+    plain=$(curl -s "$BASE_URL$endpoint")
+    decoded=$(curl -s -H "Accept-Encoding: gzip" "$BASE_URL$endpoint" | gunzip 2>/dev/null)
+
+    if [ "$encoding" == "gzip" ] && [ "$decoded" == "$plain" ]; then
+        echo -e "  ${GREEN}✓ PASS${NC} (Content-Encoding: gzip, body round-trips)"
+        pass_count=$((pass_count + 1))
+    else
+        echo -e "  ${RED}✗ FAIL${NC} (Content-Encoding: ${encoding:-none}, body matches: $([ "$decoded" == "$plain" ] && echo yes || echo no))"
+        fail_count=$((fail_count + 1))
+    fi
+    echo ""
+}
+
+# Function to check a response header after a POST
+test_header() {
+    test_count=$((test_count + 1))
+    local description=$1
+    local endpoint=$2
+    local data=$3
+    local header=$4
+    local expected_value=$5
+
+    echo -e "${BLUE}Test $test_count: $description${NC}"
+    echo "  Method: POST"
+    echo "  Endpoint: $endpoint"
+    echo "  Data: $data"
+
+    value=$(curl -s -D - -o /dev/null -X POST "$BASE_URL$endpoint" \
+        -H "Content-Type: application/json" \
+        -d "$data" | grep -i "^$header:" | cut -d' ' -f2- | tr -d '\r')
+
+    if [ "$value" == "$expected_value" ]; then
+        echo -e "  ${GREEN}✓ PASS${NC} ($header: $value)"
+        pass_count=$((pass_count + 1))
+    else
+        echo -e "  ${RED}✗ FAIL${NC} (Expected $header: $expected_value, Got: $value)"
+        fail_count=$((fail_count + 1))
+    fi
+    echo ""
+}
+
+# Function to check that a GET list response returns exactly the given
+# values, in any order, and a count to match
+test_values() {
+    test_count=$((test_count + 1))
+    local description=$1
+    local endpoint=$2
+    shift 2
+
+    echo -e "${BLUE}Test $test_count: $description${NC}"
+    echo "  Method: GET"
+    echo "  Endpoint: $endpoint"
+
+    body=$(curl -s "$BASE_URL$endpoint")
+    local got expected count
+    got=$(echo "$body" | grep -o '"value":"[^"]*"' | cut -d'"' -f4 | sort)
+    expected=$(printf '%s\n' "$@" | sort)
+    count=$(echo "$body" | grep -o '"count":[0-9]*' | cut -d: -f2)
+
+    if [ "$got" == "$expected" ] && [ "$count" == "$#" ]; then
+        echo -e "  ${GREEN}✓ PASS${NC} (Count: $count)"
+        pass_count=$((pass_count + 1))
+    else
+        echo -e "  ${RED}✗ FAIL${NC} (Expected: [$(echo $expected)] with count $#, Got: [$(echo $got)] with count $count)"
+        fail_count=$((fail_count + 1))
+    fi
+
+    echo "  Response: $body"
+    echo ""
+}
+
+test_allow() {
+    test_count=$((test_count + 1))
+    local description=$1
+    local method=$2
+    local endpoint=$3
+    local expected_allow=$4
+
+    echo -e "${BLUE}Test $test_count: $description${NC}"
+    echo "  Method: $method"
+    echo "  Endpoint: $endpoint"
+
+    headers=$(curl -s -D - -o /dev/null -X "$method" "$BASE_URL$endpoint")
+    status=$(echo "$headers" | head -n1 | cut -d' ' -f2)
+    allow=$(echo "$headers" | grep -i "^Allow:" | cut -d' ' -f2- | tr -d '\r')
+
+    if [ "$status" == "405" ] && [ "$allow" == "$expected_allow" ]; then
+        echo -e "  ${GREEN}✓ PASS${NC} (Status: 405, Allow: $allow)"
+        pass_count=$((pass_count + 1))
+    else
+        echo -e "  ${RED}✗ FAIL${NC} (Expected: 405 with Allow: $expected_allow, Got: $status with Allow: $allow)"
+        fail_count=$((fail_count + 1))
+    fi
+    echo ""
+}
+
+# start_aux_server builds the server and starts a second instance on
+# AUX_PORT with extra environment variables, for tests that need their own
+# configuration. It sets AUX_URL, or returns 1 when go is not installed or
+# the server does not come up within five seconds.
+start_aux_server() {
+    command -v go >/dev/null 2>&1 || return 1
+
+    aux_dir=$(mktemp -d)
+    AUX_URL="http://localhost:${AUX_PORT:-18099}"
+    (cd "$(dirname "$0")" && go build -o "$aux_dir/server" .) || return 1
+    env PORT="${AUX_PORT:-18099}" LOG_LEVEL=error "$@" "$aux_dir/server" &
+    aux_pid=$!
+    for _ in $(seq 50); do
+        curl -s -o /dev/null "$AUX_URL/health" && return 0
+        sleep 0.1
+    done
+    stop_aux_server
+    return 1
+}
+
+stop_aux_server() {
+    kill "$aux_pid" 2>/dev/null
+    wait "$aux_pid" 2>/dev/null
+    rm -rf "$aux_dir"
+}
+
 echo "========================================="
 echo "1. HEALTH CHECK"
 echo "========================================="
@@ -69,6 +319,104 @@ test_endpoint \
     "" \
     "200"
 
+test_json_fields \
+    "Health check reports status, uptime and store size" \
+    "/health" \
+    "status" "uptime_seconds" "stored_count"
+
+before=$(curl -s "$BASE_URL/strings?include_deleted=true&count_only=true")
+
+test_endpoint \
+    "Readiness check round-trips a sentinel through the store" \
+    "GET" \
+    "/ready" \
+    "" \
+    "200"
+
+test_count=$((test_count + 1))
+echo -e "${BLUE}Test $test_count: Readiness sentinel does not linger${NC}"
+after=$(curl -s "$BASE_URL/strings?include_deleted=true&count_only=true")
+if [ "$before" == "$after" ]; then
+    echo -e "  ${GREEN}✓ PASS${NC} ($after)"
+    pass_count=$((pass_count + 1))
+else
+    echo -e "  ${RED}✗ FAIL${NC} (before: $before, after: $after)"
+    fail_count=$((fail_count + 1))
+fi
+echo ""
+
+test_json_fields \
+    "OpenAPI description is served" \
+    "/openapi.json" \
+    "openapi" "paths" "components"
+
+test_gzip \
+    "OpenAPI description is gzip-compressed on request" \
+    "/openapi.json"
+
+test_request_id \
+    "Client X-Request-ID is echoed back" \
+    "/health" \
+    "test-trace-123"
+
+test_request_id \
+    "X-Request-ID is generated when absent" \
+    "/health" \
+    ""
+
+test_endpoint \
+    "Unknown route returns JSON 404" \
+    "GET" \
+    "/does-not-exist" \
+    "" \
+    "404"
+
+test_allow \
+    "Unsupported method returns 405 with Allow header" \
+    "PATCH" \
+    "/strings/racecar" \
+    "DELETE, GET, HEAD, PUT"
+
+test_count=$((test_count + 1))
+echo -e "${BLUE}Test $test_count: Non-standard methods are counted as 'other' in metrics${NC}"
+curl -s -o /dev/null -X MADEUPMETHOD "$BASE_URL/health"
+body=$(curl -s "$BASE_URL/metrics")
+if echo "$body" | grep -qF 'method="other",endpoint="/health"' && ! echo "$body" | grep -qF "MADEUPMETHOD"; then
+    echo -e "  ${GREEN}✓ PASS${NC}"
+    pass_count=$((pass_count + 1))
+else
+    echo -e "  ${RED}✗ FAIL${NC} (Metrics: $(echo "$body" | grep endpoint_requests_total | tr '\n' ' '))"
+    fail_count=$((fail_count + 1))
+fi
+echo ""
+
+test_allow \
+    "Fixed route advertises its methods and those of /strings/{value}" \
+    "POST" \
+    "/strings/stats" \
+    "DELETE, GET, HEAD, PUT"
+
+test_endpoint \
+    "Create a value named after a fixed route" \
+    "POST" \
+    "/strings" \
+    '{"value": "stats"}' \
+    "201"
+
+test_endpoint \
+    "DELETE falls through a fixed route to /strings/{value}" \
+    "DELETE" \
+    "/strings/stats" \
+    "" \
+    "204"
+
+test_endpoint \
+    "GET on the fixed route still serves stats" \
+    "GET" \
+    "/strings/stats" \
+    "" \
+    "200"
+
 echo "========================================="
 echo "2. CREATE STRINGS (POST /strings)"
 echo "========================================="
@@ -94,224 +442,2047 @@ test_endpoint \
     '{"value": "noon"}' \
     "201"
 
-test_endpoint \
-    "Create multi-word palindrome 'A man a plan a canal Panama'" \
-    "POST" \
-    "/strings" \
-    '{"value": "A man a plan a canal Panama"}' \
-    "201"
+test_endpoint \
+    "Create multi-word palindrome 'A man a plan a canal Panama'" \
+    "POST" \
+    "/strings" \
+    '{"value": "A man a plan a canal Panama"}' \
+    "201"
+
+test_endpoint \
+    "Create string 'testing'" \
+    "POST" \
+    "/strings" \
+    '{"value": "testing"}' \
+    "201"
+
+test_endpoint \
+    "Create duplicate string 'racecar' (should fail)" \
+    "POST" \
+    "/strings" \
+    '{"value": "racecar"}' \
+    "409"
+
+test_endpoint \
+    "Create 'RaceCar' case-insensitively (should fail)" \
+    "POST" \
+    "/strings?case_insensitive=true" \
+    '{"value": "RaceCar"}' \
+    "409"
+
+test_endpoint \
+    "Create with missing value field (should fail)" \
+    "POST" \
+    "/strings" \
+    '{}' \
+    "400"
+
+test_endpoint \
+    "Create with empty body (should fail)" \
+    "POST" \
+    "/strings" \
+    "" \
+    "400"
+
+test_endpoint \
+    "Create with malformed JSON (should fail)" \
+    "POST" \
+    "/strings" \
+    '{"value": ' \
+    "400"
+
+test_endpoint \
+    "Create with non-string value (should fail)" \
+    "POST" \
+    "/strings" \
+    '{"value": 42}' \
+    "422"
+
+test_endpoint \
+    "Create with empty value (should fail)" \
+    "POST" \
+    "/strings" \
+    '{"value": ""}' \
+    "400"
+
+test_endpoint \
+    "Bulk create with an in-batch duplicate" \
+    "POST" \
+    "/strings/bulk" \
+    '{"values": ["kayak", "rotor", "kayak"]}' \
+    "207"
+
+test_endpoint \
+    "Bulk create with missing values (should fail)" \
+    "POST" \
+    "/strings/bulk" \
+    '{}' \
+    "400"
+
+test_endpoint \
+    "Import newline-delimited text" \
+    "POST" \
+    "/strings/import" \
+    $'madam\nrefer\n\nmadam' \
+    "200"
+
+test_count=$((test_count + 1))
+echo -e "${BLUE}Test $test_count: Import skips a line that is not valid UTF-8${NC}"
+body=$(printf 'ab\xffcd\nimported ok\n' | curl -s -X POST "$BASE_URL/strings/import" \
+    -H "Content-Type: text/plain" --data-binary @-)
+status=$(curl -s -o /dev/null -w "%{http_code}" "$BASE_URL/strings/ab%FFcd")
+if echo "$body" | grep -q '"created":1,"skipped":1' && [ "$status" == "404" ]; then
+    echo -e "  ${GREEN}✓ PASS${NC} ($body)"
+    pass_count=$((pass_count + 1))
+else
+    echo -e "  ${RED}✗ FAIL${NC} (Response: $body, GET invalid line: $status)"
+    fail_count=$((fail_count + 1))
+fi
+echo ""
+
+test_response_contains \
+    "Bulk create reports a lone surrogate escape as invalid" \
+    "POST" \
+    "/strings/bulk" \
+    '{"values": ["x\ud800y", "bulk ok"]}' \
+    "207" \
+    '"created":1' \
+    '"status":"invalid","error":"Value is not valid UTF-8"'
+
+test_response_contains \
+    "Create multi-word string (word_frequency_map the:2, cat:1, dog:1)" \
+    "POST" \
+    "/strings" \
+    '{"value": "the cat the dog"}' \
+    "201" \
+    '"word_frequency_map":{"cat":1,"dog":1,"the":2}'
+
+test_response_contains \
+    "Create mixed-language string (title_case Élan ǅungla Strasse Straße Привет Мир)" \
+    "POST" \
+    "/strings" \
+    '{"value": "élan ǆungla STRASSE straße ПРИВЕТ мир"}' \
+    "201" \
+    '"uppercase":"ÉLAN ǄUNGLA STRASSE STRAßE ПРИВЕТ МИР"' \
+    '"lowercase":"élan ǆungla strasse straße привет мир"' \
+    '"title_case":"Élan ǅungla Strasse Straße Привет Мир"'
+
+test_header \
+    "Create returns escaped Location header" \
+    "/strings" \
+    '{"value": "new york city"}' \
+    "Location" \
+    "/strings/new%20york%20city"
+
+test_response_contains \
+    "Create repeating string 'abab' (repeat_unit ab)" \
+    "POST" \
+    "/strings" \
+    '{"value": "abab"}' \
+    "201" \
+    '"is_repeating":true,"repeat_unit":"ab"'
+
+test_response_contains \
+    "Create repeating string 'aaaa' (repeat_unit a)" \
+    "POST" \
+    "/strings" \
+    '{"value": "aaaa"}' \
+    "201" \
+    '"is_repeating":true,"repeat_unit":"a"'
+
+test_response_contains \
+    "Create non-repeating string 'abcab' (is_repeating false)" \
+    "POST" \
+    "/strings" \
+    '{"value": "abcab"}' \
+    "201" \
+    '"is_repeating":false,"repeat_unit":""'
+
+test_endpoint \
+    "Create with normalize=whitespace (stored as 'snow fall')" \
+    "POST" \
+    "/strings?normalize=whitespace" \
+    '{"value": "  snow \t\n fall  "}' \
+    "201"
+
+test_endpoint \
+    "Normalized near-duplicate 'snow fall ' conflicts (should fail)" \
+    "POST" \
+    "/strings?normalize=whitespace" \
+    '{"value": "snow fall "}' \
+    "409"
+
+test_endpoint \
+    "Get normalized string 'snow fall'" \
+    "GET" \
+    "/strings/snow%20fall" \
+    "" \
+    "200"
+
+test_endpoint \
+    "Create whitespace-only value with normalize=whitespace (should fail)" \
+    "POST" \
+    "/strings?normalize=whitespace" \
+    '{"value": "   "}' \
+    "400"
+
+test_count=$((test_count + 1))
+echo -e "${BLUE}Test $test_count: Create base64-encoded value stores the decoded string${NC}"
+body=$(curl -s -X POST "$BASE_URL/strings" \
+    -H "Content-Type: application/json" -d '{"value": "aGVsbG8Jd29ybGQ=", "encoding": "base64"}')
+if echo "$body" | grep -q '"value":"hello\\tworld"'; then
+    echo -e "  ${GREEN}✓ PASS${NC} (stored \"hello<TAB>world\")"
+    pass_count=$((pass_count + 1))
+else
+    echo -e "  ${RED}✗ FAIL${NC} (Got: $body)"
+    fail_count=$((fail_count + 1))
+fi
+echo ""
+
+test_count=$((test_count + 1))
+echo -e "${BLUE}Test $test_count: Create with palindrome_mode=strict stores the mode${NC}"
+curl -s -o /dev/null -X POST "$BASE_URL/strings?palindrome_mode=strict" \
+    -H "Content-Type: application/json" -d '{"value": "Level"}'
+body=$(curl -s "$BASE_URL/strings/Level")
+if echo "$body" | grep -q '"is_palindrome":false,"palindrome_mode":"strict"'; then
+    echo -e "  ${GREEN}✓ PASS${NC}"
+    pass_count=$((pass_count + 1))
+else
+    echo -e "  ${RED}✗ FAIL${NC} (Response: $body)"
+    fail_count=$((fail_count + 1))
+fi
+echo ""
+
+test_endpoint \
+    "Create with invalid base64 (should fail)" \
+    "POST" \
+    "/strings" \
+    '{"value": "not base64!", "encoding": "base64"}' \
+    "400"
+
+# "b2v/ay4=" decodes to "ok", a lone 0xFF byte, then "k."
+test_endpoint \
+    "Create base64 value that is not valid UTF-8 (should fail)" \
+    "POST" \
+    "/strings" \
+    '{"value": "b2v/ay4=", "encoding": "base64"}' \
+    "400"
+
+test_count=$((test_count + 1))
+echo -e "${BLUE}Test $test_count: Create invalid UTF-8 with sanitize=true replaces the bad byte${NC}"
+body=$(curl -s -X POST "$BASE_URL/strings?sanitize=true" \
+    -H "Content-Type: application/json" -d '{"value": "b2v/ay4=", "encoding": "base64"}')
+if echo "$body" | grep -q '"value":"ok�k\."'; then
+    echo -e "  ${GREEN}✓ PASS${NC} (stored \"ok�k.\")"
+    pass_count=$((pass_count + 1))
+else
+    echo -e "  ${RED}✗ FAIL${NC} (Got: $body)"
+    fail_count=$((fail_count + 1))
+fi
+echo ""
+
+test_count=$((test_count + 1))
+echo -e "${BLUE}Test $test_count: Create with a raw invalid UTF-8 byte (should fail)${NC}"
+status=$(printf '{"value": "ab\xffcd"}' | curl -s -o /dev/null -w "%{http_code}" -X POST "$BASE_URL/strings" \
+    -H "Content-Type: application/json" --data-binary @-)
+if [ "$status" == "400" ]; then
+    echo -e "  ${GREEN}✓ PASS${NC} (Status: $status)"
+    pass_count=$((pass_count + 1))
+else
+    echo -e "  ${RED}✗ FAIL${NC} (Expected: 400, Got: $status)"
+    fail_count=$((fail_count + 1))
+fi
+echo ""
+
+test_endpoint \
+    "Create with a lone surrogate escape (should fail)" \
+    "POST" \
+    "/strings" \
+    '{"value": "x\ud800y"}' \
+    "400"
+
+test_endpoint \
+    "Create with unknown encoding (should fail)" \
+    "POST" \
+    "/strings" \
+    '{"value": "hello", "encoding": "hex"}' \
+    "400"
+
+test_endpoint \
+    "Create 'cafe' plus combining accent with normalize=nfc (stored as 'café')" \
+    "POST" \
+    "/strings?normalize=nfc" \
+    '{"value": "cafe\u0301"}' \
+    "201"
+
+test_endpoint \
+    "Precomposed 'café' conflicts with the NFC-normalized entry (should fail)" \
+    "POST" \
+    "/strings?normalize=nfc" \
+    '{"value": "café"}' \
+    "409"
+
+test_endpoint \
+    "Get NFC-normalized string by its precomposed form" \
+    "GET" \
+    "/strings/caf%C3%A9" \
+    "" \
+    "200"
+
+test_endpoint \
+    "Create with unknown normalize mode (should fail)" \
+    "POST" \
+    "/strings?normalize=nfd" \
+    '{"value": "hello"}' \
+    "400"
+
+before=$(curl -s "$BASE_URL/strings?count_only=true")
+
+test_endpoint \
+    "Analyze 'racecar' without storing (no conflict)" \
+    "POST" \
+    "/strings/analyze" \
+    '{"value": "racecar"}' \
+    "200"
+
+test_endpoint \
+    "Analyze new value 'preview only' without storing" \
+    "POST" \
+    "/strings/analyze" \
+    '{"value": "preview only"}' \
+    "200"
+
+test_count=$((test_count + 1))
+echo -e "${BLUE}Test $test_count: Store size unchanged after analyze${NC}"
+after=$(curl -s "$BASE_URL/strings?count_only=true")
+if [ "$before" == "$after" ]; then
+    echo -e "  ${GREEN}✓ PASS${NC} ($after)"
+    pass_count=$((pass_count + 1))
+else
+    echo -e "  ${RED}✗ FAIL${NC} (before: $before, after: $after)"
+    fail_count=$((fail_count + 1))
+fi
+echo ""
+
+test_count=$((test_count + 1))
+echo -e "${BLUE}Test $test_count: GET analyze matches POST analyze${NC}"
+get_props=$(curl -s "$BASE_URL/strings/analyze?value=hello%20world" | sed 's/,"created_at".*//')
+post_props=$(curl -s -X POST "$BASE_URL/strings/analyze" \
+    -H "Content-Type: application/json" -d '{"value": "hello world"}' | sed 's/,"created_at".*//')
+if [ -n "$get_props" ] && [ "$get_props" == "$post_props" ]; then
+    echo -e "  ${GREEN}✓ PASS${NC}"
+    pass_count=$((pass_count + 1))
+else
+    echo -e "  ${RED}✗ FAIL${NC} (GET: $get_props, POST: $post_props)"
+    fail_count=$((fail_count + 1))
+fi
+echo ""
+
+test_endpoint \
+    "GET analyze without a value (should fail)" \
+    "GET" \
+    "/strings/analyze" \
+    "" \
+    "400"
+
+test_endpoint \
+    "Get analyzed-only value (should fail)" \
+    "GET" \
+    "/strings/preview%20only" \
+    "" \
+    "404"
+
+test_count=$((test_count + 1))
+echo -e "${BLUE}Test $test_count: Analyze with fields=length,is_palindrome returns only those properties${NC}"
+body=$(curl -s -X POST "$BASE_URL/strings/analyze?fields=length,is_palindrome" \
+    -H "Content-Type: application/json" -d '{"value": "racecar"}')
+if echo "$body" | grep -q '"length":7' && echo "$body" | grep -q '"is_palindrome":true' \
+    && ! echo "$body" | grep -q '"sha256_hash"' && ! echo "$body" | grep -q '"word_count"'; then
+    echo -e "  ${GREEN}✓ PASS${NC}"
+    pass_count=$((pass_count + 1))
+else
+    echo -e "  ${RED}✗ FAIL${NC} (Response: $body)"
+    fail_count=$((fail_count + 1))
+fi
+echo ""
+
+# Character-class summary: value|expected properties (keys sorted)
+while IFS='|' read -r value expected; do
+    test_count=$((test_count + 1))
+    echo -e "${BLUE}Test $test_count: Character-class summary of '$value'${NC}"
+    body=$(curl -s -X POST "$BASE_URL/strings/analyze?fields=is_numeric,is_alpha,is_alphanumeric,has_whitespace" \
+        -H "Content-Type: application/json" -d "{\"value\": \"$value\"}")
+    if echo "$body" | grep -qF "\"properties\":$expected"; then
+        echo -e "  ${GREEN}✓ PASS${NC}"
+        pass_count=$((pass_count + 1))
+    else
+        echo -e "  ${RED}✗ FAIL${NC} (Response: $body)"
+        fail_count=$((fail_count + 1))
+    fi
+    echo ""
+done <<'EOF'
+12345|{"has_whitespace":false,"is_alpha":false,"is_alphanumeric":true,"is_numeric":true}
+abcDEF|{"has_whitespace":false,"is_alpha":true,"is_alphanumeric":true,"is_numeric":false}
+a1b2|{"has_whitespace":false,"is_alpha":false,"is_alphanumeric":true,"is_numeric":false}
+hello world|{"has_whitespace":true,"is_alpha":false,"is_alphanumeric":false,"is_numeric":false}
+EOF
+
+# Palindrome modes: mode|value|expected is_palindrome
+while IFS='|' read -r mode value expected; do
+    test_count=$((test_count + 1))
+    echo -e "${BLUE}Test $test_count: '$value' with palindrome_mode=$mode${NC}"
+    body=$(curl -s -X POST "$BASE_URL/strings/analyze?fields=is_palindrome,palindrome_mode&palindrome_mode=$mode" \
+        -H "Content-Type: application/json" -d "{\"value\": \"$value\"}")
+    if echo "$body" | grep -qF "\"properties\":{\"is_palindrome\":$expected,\"palindrome_mode\":\"$mode\"}"; then
+        echo -e "  ${GREEN}✓ PASS${NC}"
+        pass_count=$((pass_count + 1))
+    else
+        echo -e "  ${RED}✗ FAIL${NC} (Response: $body)"
+        fail_count=$((fail_count + 1))
+    fi
+    echo ""
+done <<'EOF'
+strict|A man, a plan, a canal: Panama|false
+ci|A man, a plan, a canal: Panama|false
+alphanumeric|A man, a plan, a canal: Panama|true
+strict|Racecar|false
+ci|Racecar|true
+strict|racecar|true
+EOF
+
+test_count=$((test_count + 1))
+echo -e "${BLUE}Test $test_count: Default palindrome_mode is alphanumeric${NC}"
+body=$(curl -s -X POST "$BASE_URL/strings/analyze?fields=is_palindrome,palindrome_mode" \
+    -H "Content-Type: application/json" -d '{"value": "A man, a plan, a canal: Panama"}')
+if echo "$body" | grep -qF '"properties":{"is_palindrome":true,"palindrome_mode":"alphanumeric"}'; then
+    echo -e "  ${GREEN}✓ PASS${NC}"
+    pass_count=$((pass_count + 1))
+else
+    echo -e "  ${RED}✗ FAIL${NC} (Response: $body)"
+    fail_count=$((fail_count + 1))
+fi
+echo ""
+
+test_endpoint \
+    "Analyze with an unknown palindrome_mode (should fail)" \
+    "POST" \
+    "/strings/analyze?palindrome_mode=loose" \
+    '{"value": "racecar"}' \
+    "400"
+
+# Grapheme clusters: value|expected length and grapheme count. A flag is two
+# regional indicators; the family emoji joins three people with two ZWJs, and
+# café is spelled with a combining accent.
+while IFS='|' read -r value expected; do
+    test_count=$((test_count + 1))
+    echo -e "${BLUE}Test $test_count: Grapheme count of '$value'${NC}"
+    body=$(curl -s -X POST "$BASE_URL/strings/analyze?fields=length,grapheme_count" \
+        -H "Content-Type: application/json" -d "{\"value\": \"$value\"}")
+    if echo "$body" | grep -qF "\"properties\":$expected"; then
+        echo -e "  ${GREEN}✓ PASS${NC}"
+        pass_count=$((pass_count + 1))
+    else
+        echo -e "  ${RED}✗ FAIL${NC} (Response: $body)"
+        fail_count=$((fail_count + 1))
+    fi
+    echo ""
+done <<'EOF'
+🇰🇪|{"grapheme_count":1,"length":2}
+👨‍👩‍👧|{"grapheme_count":1,"length":5}
+café|{"grapheme_count":4,"length":5}
+EOF
+
+test_endpoint \
+    "Analyze with an unknown field (should fail)" \
+    "POST" \
+    "/strings/analyze?fields=length,colour" \
+    '{"value": "racecar"}' \
+    "400"
+
+test_endpoint \
+    "Create with fields=word_count" \
+    "POST" \
+    "/strings?fields=word_count" \
+    '{"value": "fields are trimmed"}' \
+    "201"
+
+test_json_fields \
+    "Stored entry keeps every property despite fields" \
+    "/strings/fields%20are%20trimmed" \
+    "length" "is_palindrome" "sha256_hash" "word_count"
+
+test_endpoint \
+    "Upsert new value 'upserted'" \
+    "POST" \
+    "/strings?upsert=true" \
+    '{"value": "upserted"}' \
+    "201"
+
+test_endpoint \
+    "Upsert existing value 'upserted' returns it" \
+    "POST" \
+    "/strings?upsert=true" \
+    '{"value": "upserted"}' \
+    "200"
+
+test_endpoint \
+    "Upsert 'UPSERTED' case-insensitively returns the stored value" \
+    "POST" \
+    "/strings?upsert=true&case_insensitive=true" \
+    '{"value": "UPSERTED"}' \
+    "200"
+
+test_endpoint \
+    "Create existing 'upserted' without upsert (should fail)" \
+    "POST" \
+    "/strings" \
+    '{"value": "upserted"}' \
+    "409"
+
+test_endpoint \
+    "Create empty string without allow_empty (should fail)" \
+    "POST" \
+    "/strings" \
+    '{"value": ""}' \
+    "400"
+
+test_endpoint \
+    "Create empty string with allow_empty=true" \
+    "POST" \
+    "/strings?allow_empty=true" \
+    '{"value": ""}' \
+    "201"
+
+test_endpoint \
+    "Get empty string by id" \
+    "GET" \
+    "/strings/by-id/e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855" \
+    "" \
+    "200"
+
+echo "========================================="
+echo "3. GET SPECIFIC STRING"
+echo "========================================="
+
+test_endpoint \
+    "Get string 'racecar'" \
+    "GET" \
+    "/strings/racecar" \
+    "" \
+    "200"
+
+test_endpoint \
+    "Get string 'hello world' (URL encoded)" \
+    "GET" \
+    "/strings/hello%20world" \
+    "" \
+    "200"
+
+test_endpoint \
+    "Get 'racecar' with non-matching If-None-Match" \
+    "GET" \
+    "/strings/racecar" \
+    "" \
+    "200" \
+    'If-None-Match: "stale"'
+
+racecar_etag=$(curl -s -D - -o /dev/null "$BASE_URL/strings/racecar" | tr -d '\r' | grep -i '^etag:' | cut -d' ' -f2-)
+
+test_endpoint \
+    "Get 'racecar' with matching If-None-Match (not modified)" \
+    "GET" \
+    "/strings/racecar" \
+    "" \
+    "304" \
+    "If-None-Match: $racecar_etag"
+
+test_endpoint \
+    "Create string containing a slash 'and/or'" \
+    "POST" \
+    "/strings" \
+    '{"value": "and/or"}' \
+    "201"
+
+test_endpoint \
+    "Get string 'and/or' (slash percent-encoded)" \
+    "GET" \
+    "/strings/and%2For" \
+    "" \
+    "200"
+
+test_endpoint \
+    "Get non-existent string (should fail)" \
+    "GET" \
+    "/strings/nonexistent" \
+    "" \
+    "404"
+
+test_endpoint \
+    "Find strings similar to 'racecars'" \
+    "GET" \
+    "/strings/racecars/similar?max_distance=2" \
+    "" \
+    "200"
+
+test_endpoint \
+    "Similar with invalid max_distance (should fail)" \
+    "GET" \
+    "/strings/racecar/similar?max_distance=abc" \
+    "" \
+    "400"
+
+test_endpoint \
+    "Create a value named after the similar sub-route" \
+    "POST" \
+    "/strings" \
+    '{"value": "similar"}' \
+    "201"
+
+test_response_contains \
+    "Get 'similar' returns the entry, not a similar-strings list" \
+    "GET" \
+    "/strings/similar" \
+    "" \
+    "200" \
+    '"value":"similar","properties"'
+
+test_endpoint \
+    "Delete 'similar'" \
+    "DELETE" \
+    "/strings/similar" \
+    "" \
+    "204"
+
+test_endpoint \
+    "Get anagram groups" \
+    "GET" \
+    "/strings/anagrams" \
+    "" \
+    "200"
+
+test_endpoint \
+    "Get duplicate IDs" \
+    "GET" \
+    "/strings/duplicates" \
+    "" \
+    "200"
+
+# Diff is a pure computation, so the expected values do not depend on the store
+test_response_contains \
+    "Diff identical strings" \
+    "GET" \
+    "/strings/diff?a=same&b=same" \
+    "" \
+    "200" \
+    '"common_prefix_length":4,' \
+    '"levenshtein_distance":0,' \
+    '"is_anagram":true'
+
+test_response_contains \
+    "Diff anagrams 'listen' and 'silent'" \
+    "GET" \
+    "/strings/diff?a=listen&b=silent" \
+    "" \
+    "200" \
+    '"common_prefix_length":0,' \
+    '"levenshtein_distance":4,' \
+    '"is_anagram":true'
+
+test_response_contains \
+    "Diff completely different strings" \
+    "GET" \
+    "/strings/diff?a=abc&b=xyz" \
+    "" \
+    "200" \
+    '"common_prefix_length":0,' \
+    '"levenshtein_distance":3,' \
+    '"is_anagram":false'
+
+test_endpoint \
+    "Diff without b (should fail)" \
+    "GET" \
+    "/strings/diff?a=abc" \
+    "" \
+    "400"
+
+# Longest common substring: a|b|expected substring
+while IFS='|' read -r a b expected; do
+    test_count=$((test_count + 1))
+    echo -e "${BLUE}Test $test_count: Longest common substring of '$a' and '$b'${NC}"
+    body=$(curl -s "$BASE_URL/strings/lcs?a=$a&b=$b")
+    if echo "$body" | grep -qF "\"substring\":\"$expected\""; then
+        echo -e "  ${GREEN}✓ PASS${NC} ($body)"
+        pass_count=$((pass_count + 1))
+    else
+        echo -e "  ${RED}✗ FAIL${NC} (Response: $body)"
+        fail_count=$((fail_count + 1))
+    fi
+    echo ""
+done <<'EOF'
+xabcdey|zzbcdeq|bcde
+overlapping|lapdog|lap
+abc|xyz|
+|xyz|
+EOF
+
+test_endpoint \
+    "LCS without b (should fail)" \
+    "GET" \
+    "/strings/lcs?a=abc" \
+    "" \
+    "400"
+
+test_endpoint \
+    "Get aggregate statistics" \
+    "GET" \
+    "/strings/stats" \
+    "" \
+    "200"
+
+for v in "ʘʬʘ" "ʬʬ"; do
+    curl -s -o /dev/null -X POST "$BASE_URL/strings" \
+        -H "Content-Type: application/json" -d "{\"value\": \"$v\"}"
+done
+
+test_count=$((test_count + 1))
+echo -e "${BLUE}Test $test_count: Charset merges overlapping characters in rune order${NC}"
+body=$(curl -s "$BASE_URL/strings/charset")
+if echo "$body" | grep -q '"ʘ":2,"ʬ":3'; then
+    echo -e "  ${GREEN}✓ PASS${NC} (ʘ: 2, ʬ: 3)"
+    pass_count=$((pass_count + 1))
+else
+    echo -e "  ${RED}✗ FAIL${NC} (Got: $body)"
+    fail_count=$((fail_count + 1))
+fi
+echo ""
+
+test_endpoint \
+    "Get a random string" \
+    "GET" \
+    "/strings/random" \
+    "" \
+    "200"
+
+test_endpoint \
+    "Get 3 distinct random strings" \
+    "GET" \
+    "/strings/random?count=3" \
+    "" \
+    "200"
+
+test_endpoint \
+    "Random with invalid count (should fail)" \
+    "GET" \
+    "/strings/random?count=0" \
+    "" \
+    "400"
+
+for v in "zebra crossing" "zebra" "a zebra" "Zebra"; do
+    curl -s -o /dev/null -X POST "$BASE_URL/strings" \
+        -H "Content-Type: application/json" -d "{\"value\": \"$v\"}"
+done
+
+test_count=$((test_count + 1))
+echo -e "${BLUE}Test $test_count: Search ranks exact, then prefix, then substring${NC}"
+order=$(curl -s "$BASE_URL/strings/search?q=zebra" | grep -o '"value":"[^"]*"' | tr '\n' ' ')
+if [ "$order" == '"value":"zebra" "value":"zebra crossing" "value":"a zebra" ' ]; then
+    echo -e "  ${GREEN}✓ PASS${NC} ($order)"
+    pass_count=$((pass_count + 1))
+else
+    echo -e "  ${RED}✗ FAIL${NC} (Order: $order)"
+    fail_count=$((fail_count + 1))
+fi
+echo ""
+
+test_count=$((test_count + 1))
+echo -e "${BLUE}Test $test_count: Case-insensitive search with limit=2 keeps the top matches${NC}"
+order=$(curl -s "$BASE_URL/strings/search?q=ZEBRA&case_insensitive=true&limit=2" | grep -o '"value":"[^"]*"' | tr '\n' ' ')
+if [ "$order" == '"value":"Zebra" "value":"zebra" ' ]; then
+    echo -e "  ${GREEN}✓ PASS${NC} ($order)"
+    pass_count=$((pass_count + 1))
+else
+    echo -e "  ${RED}✗ FAIL${NC} (Order: $order)"
+    fail_count=$((fail_count + 1))
+fi
+echo ""
+
+test_endpoint \
+    "Search without q (should fail)" \
+    "GET" \
+    "/strings/search" \
+    "" \
+    "400"
+
+test_endpoint \
+    "Get 'racecar' by SHA-256 id" \
+    "GET" \
+    "/strings/by-id/e00f9ef51a95f6e854862eed28dc0f1a68f154d9f75ddd841ab00de6ede9209b" \
+    "" \
+    "200"
+
+test_endpoint \
+    "Get unknown id (should fail)" \
+    "GET" \
+    "/strings/by-id/0000" \
+    "" \
+    "404"
+
+test_count=$((test_count + 1))
+echo -e "${BLUE}Test $test_count: Frequency map of 'racecar'${NC}"
+body=$(curl -s "$BASE_URL/strings/racecar/frequency")
+if [ "$body" == '{"a":2,"c":2,"e":1,"r":2}' ]; then
+    echo -e "  ${GREEN}✓ PASS${NC} ($body)"
+    pass_count=$((pass_count + 1))
+else
+    echo -e "  ${RED}✗ FAIL${NC} (Response: $body)"
+    fail_count=$((fail_count + 1))
+fi
+echo ""
+
+test_count=$((test_count + 1))
+echo -e "${BLUE}Test $test_count: Sorted frequency array of 'racecar'${NC}"
+body=$(curl -s "$BASE_URL/strings/racecar/frequency?sorted=true")
+if [ "$body" == '[{"char":"a","count":2},{"char":"c","count":2},{"char":"r","count":2},{"char":"e","count":1}]' ]; then
+    echo -e "  ${GREEN}✓ PASS${NC} ($body)"
+    pass_count=$((pass_count + 1))
+else
+    echo -e "  ${RED}✗ FAIL${NC} (Response: $body)"
+    fail_count=$((fail_count + 1))
+fi
+echo ""
+
+test_count=$((test_count + 1))
+echo -e "${BLUE}Test $test_count: pretty=true indents the same payload${NC}"
+compact=$(curl -s "$BASE_URL/strings/racecar/frequency")
+pretty=$(curl -s "$BASE_URL/strings/racecar/frequency?pretty=true")
+expected_pretty=$(printf '{\n  "a": 2,\n  "c": 2,\n  "e": 1,\n  "r": 2\n}')
+if [ "$pretty" == "$expected_pretty" ] && [ "$(echo "$pretty" | tr -d ' \n')" == "$compact" ]; then
+    echo -e "  ${GREEN}✓ PASS${NC}"
+    pass_count=$((pass_count + 1))
+else
+    echo -e "  ${RED}✗ FAIL${NC} (compact: $compact, pretty: $pretty)"
+    fail_count=$((fail_count + 1))
+fi
+echo ""
+
+test_endpoint \
+    "Frequency of non-existent string (should fail)" \
+    "GET" \
+    "/strings/nonexistent/frequency" \
+    "" \
+    "404"
+
+test_count=$((test_count + 1))
+echo -e "${BLUE}Test $test_count: Palindromic substrings of 'racecar'${NC}"
+body=$(curl -s "$BASE_URL/strings/racecar/palindromes")
+if [ "$body" == '{"count":3,"data":[{"substring":"racecar","start":0,"length":7},{"substring":"aceca","start":1,"length":5},{"substring":"cec","start":2,"length":3}],"limit":50,"offset":0,"total":3,"value":"racecar"}' ]; then
+    echo -e "  ${GREEN}✓ PASS${NC}"
+    pass_count=$((pass_count + 1))
+else
+    echo -e "  ${RED}✗ FAIL${NC} (Response: $body)"
+    fail_count=$((fail_count + 1))
+fi
+echo ""
+
+test_response_contains \
+    "Palindromic substrings of 'aaaa' are paged" \
+    "GET" \
+    "/strings/aaaa/palindromes?limit=1&offset=1" \
+    "" \
+    "200" \
+    '"count":1,"data":[{"substring":"aaa","start":0,"length":3}]' \
+    '"total":3'
+
+curl -s -o /dev/null -X POST "$BASE_URL/strings" \
+    -H "Content-Type: application/json" -d '{"value": "wordplay"}'
+
+test_count=$((test_count + 1))
+echo -e "${BLUE}Test $test_count: No palindromic substrings in 'wordplay'${NC}"
+body=$(curl -s "$BASE_URL/strings/wordplay/palindromes")
+if echo "$body" | grep -q '"count":0,"data":\[\]'; then
+    echo -e "  ${GREEN}✓ PASS${NC} ($body)"
+    pass_count=$((pass_count + 1))
+else
+    echo -e "  ${RED}✗ FAIL${NC} (Response: $body)"
+    fail_count=$((fail_count + 1))
+fi
+echo ""
+
+test_count=$((test_count + 1))
+echo -e "${BLUE}Test $test_count: Batch get reports present and missing values${NC}"
+body=$(curl -s -X POST "$BASE_URL/strings/batch-get" \
+    -H "Content-Type: application/json" -d '{"values": ["racecar", "not-stored-1", "wordplay", "not-stored-2", "not-stored-1"]}')
+if echo "$body" | grep -q '"found":{"racecar":{' && echo "$body" | grep -q '"wordplay":{' \
+    && echo "$body" | grep -q '"missing":\["not-stored-1","not-stored-2"\]'; then
+    echo -e "  ${GREEN}✓ PASS${NC}"
+    pass_count=$((pass_count + 1))
+else
+    echo -e "  ${RED}✗ FAIL${NC} (Response: $body)"
+    fail_count=$((fail_count + 1))
+fi
+echo ""
+
+test_endpoint \
+    "Batch get without values (should fail)" \
+    "POST" \
+    "/strings/batch-get" \
+    '{"values": []}' \
+    "400"
+
+test_endpoint \
+    "Palindromes with invalid min (should fail)" \
+    "GET" \
+    "/strings/racecar/palindromes?min=0" \
+    "" \
+    "400"
+
+test_endpoint \
+    "Palindromes of non-existent string (should fail)" \
+    "GET" \
+    "/strings/nonexistent/palindromes" \
+    "" \
+    "404"
+
+test_endpoint \
+    "Check 'racecar' exists" \
+    "GET" \
+    "/strings/racecar/exists" \
+    "" \
+    "200"
+
+test_endpoint \
+    "Check non-existent string exists (should fail)" \
+    "GET" \
+    "/strings/nonexistent/exists" \
+    "" \
+    "404"
+
+# HEAD on the /exists route answers like GET: value|expected status
+while IFS='|' read -r value expected_status; do
+    test_count=$((test_count + 1))
+    echo -e "${BLUE}Test $test_count: HEAD /strings/$value/exists returns $expected_status${NC}"
+    http_code=$(curl -s -I -o /dev/null -w "%{http_code}" "$BASE_URL/strings/$value/exists")
+    if [ "$http_code" == "$expected_status" ]; then
+        echo -e "  ${GREEN}✓ PASS${NC} (Status: $http_code)"
+        pass_count=$((pass_count + 1))
+    else
+        echo -e "  ${RED}✗ FAIL${NC} (Expected: $expected_status, Got: $http_code)"
+        fail_count=$((fail_count + 1))
+    fi
+    echo ""
+done <<'EOF'
+racecar|200
+nonexistent|404
+EOF
+
+test_endpoint \
+    "Export strings as CSV" \
+    "GET" \
+    "/strings/export?format=csv" \
+    "" \
+    "200"
+
+test_endpoint \
+    "Export with unknown format (should fail)" \
+    "GET" \
+    "/strings/export?format=xml" \
+    "" \
+    "400"
+
+echo "========================================="
+echo "4. GET ALL STRINGS WITH FILTERS"
+echo "========================================="
+
+test_endpoint \
+    "Get all strings (no filters)" \
+    "GET" \
+    "/strings" \
+    "" \
+    "200"
+
+test_endpoint \
+    "Get palindromes only" \
+    "GET" \
+    "/strings?is_palindrome=true" \
+    "" \
+    "200"
+
+test_endpoint \
+    "Get non-palindromes" \
+    "GET" \
+    "/strings?is_palindrome=false" \
+    "" \
+    "200"
+
+for v in "The quick brown fox jumps over the lazy dog" "a lazy afternoon"; do
+    curl -s -o /dev/null -X POST "$BASE_URL/strings" \
+        -H "Content-Type: application/json" -d "{\"value\": \"$v\"}"
+done
+
+# is_pangram filter: filter value|value listed|value left out
+while IFS='|' read -r filter present absent; do
+    test_count=$((test_count + 1))
+    echo -e "${BLUE}Test $test_count: Filter is_pangram=$filter among strings containing 'lazy'${NC}"
+    body=$(curl -s "$BASE_URL/strings?is_pangram=$filter&contains_substring=lazy")
+    if echo "$body" | grep -qF "\"value\":\"$present\"" && ! echo "$body" | grep -qF "\"value\":\"$absent\"" \
+        && echo "$body" | grep -qF "\"is_pangram\":$filter,\"contains_substring\""; then
+        echo -e "  ${GREEN}✓ PASS${NC}"
+        pass_count=$((pass_count + 1))
+    else
+        echo -e "  ${RED}✗ FAIL${NC} (Response: $body)"
+        fail_count=$((fail_count + 1))
+    fi
+    echo ""
+done <<'EOF'
+true|The quick brown fox jumps over the lazy dog|a lazy afternoon
+false|a lazy afternoon|The quick brown fox jumps over the lazy dog
+EOF
+
+test_endpoint \
+    "Get single word strings" \
+    "GET" \
+    "/strings?word_count=1" \
+    "" \
+    "200"
+
+test_endpoint \
+    "Get strings with min_length=5" \
+    "GET" \
+    "/strings?min_length=5" \
+    "" \
+    "200"
+
+test_endpoint \
+    "Get strings with max_length=10" \
+    "GET" \
+    "/strings?max_length=10" \
+    "" \
+    "200"
+
+test_endpoint \
+    "Get strings with min and max length" \
+    "GET" \
+    "/strings?min_length=4&max_length=10" \
+    "" \
+    "200"
+
+test_endpoint \
+    "Get strings containing 'o'" \
+    "GET" \
+    "/strings?contains_character=o" \
+    "" \
+    "200"
+
+test_values \
+    "Get strings containing 'PLAN' case-insensitively" \
+    "/strings?contains_substring=PLAN&case_insensitive=true" \
+    "A man a plan a canal Panama"
+
+test_values \
+    "Get strings containing 'PLAN' case-sensitively (no strings)" \
+    "/strings?contains_substring=PLAN"
+
+for value in reviver render rotor; do
+    curl -s -o /dev/null -X POST "$BASE_URL/strings" \
+        -H "Content-Type: application/json" -d "{\"value\": \"$value\"}"
+done
+
+# render is not a palindrome and rotor does not start with "re"
+test_values \
+    "Combined filters: starts_with 're' + ends_with 'r' + palindrome" \
+    "/strings?starts_with=re&ends_with=r&is_palindrome=true" \
+    "refer" "reviver"
+
+test_values \
+    "Get strings matching pattern ^r[a-z]*r$" \
+    "/strings?pattern=%5Er%5Ba-z%5D*r%24" \
+    "racecar" "refer" "render" "reviver" "rotor"
+
+test_endpoint \
+    "Invalid pattern (should fail)" \
+    "GET" \
+    "/strings?pattern=%5Ba-" \
+    "" \
+    "400"
+
+for value in aabb abcd abcdef; do
+    curl -s -o /dev/null -X POST "$BASE_URL/strings" \
+        -H "Content-Type: application/json" -d "{\"value\": \"$value\"}"
+done
+
+# The pattern narrows the store to the values starting with a and built
+# from a-f. Entropies: aaaa 0, aabb 1, abab 1, abcab 1.52, abcd 2, abcdef 2.585
+test_values \
+    "Get high-entropy strings" \
+    "/strings?min_entropy=2.5&pattern=%5Ea%5Ba-f%5D%2B%24" \
+    "abcdef"
+
+test_values \
+    "Entropy bounds are inclusive (abcd has entropy 2)" \
+    "/strings?min_entropy=2&max_entropy=2&pattern=%5Ea%5Ba-f%5D%2B%24" \
+    "abcd"
+
+test_endpoint \
+    "Non-numeric max_entropy (should fail)" \
+    "GET" \
+    "/strings?max_entropy=high" \
+    "" \
+    "400"
+
+test_response_contains \
+    "Get 'aaaa' (one unique character)" \
+    "GET" \
+    "/strings/aaaa" \
+    "" \
+    "200" \
+    '"unique_characters":1,'
+
+# Unique characters: aaaa 1, aabb 2, abab 2, abcab 3, abcd 4, abcdef 6
+test_values \
+    "Get strings with at most 3 unique characters" \
+    "/strings?max_unique_chars=3&pattern=%5Ea%5Ba-f%5D%2B%24" \
+    "aaaa" "aabb" "abab" "abcab"
+
+test_values \
+    "Get strings with 2 to 4 unique characters" \
+    "/strings?min_unique_chars=2&max_unique_chars=4&pattern=%5Ea%5Ba-f%5D%2B%24" \
+    "aabb" "abab" "abcab" "abcd"
+
+test_endpoint \
+    "Combined filters: palindrome + single word" \
+    "GET" \
+    "/strings?is_palindrome=true&word_count=1" \
+    "" \
+    "200"
+
+test_endpoint \
+    "Paginate with limit and offset" \
+    "GET" \
+    "/strings?limit=2&offset=1" \
+    "" \
+    "200"
+
+test_endpoint \
+    "Non-numeric min_length (should fail)" \
+    "GET" \
+    "/strings?min_length=abc" \
+    "" \
+    "400"
+
+test_endpoint \
+    "Negative offset (should fail)" \
+    "GET" \
+    "/strings?offset=-1" \
+    "" \
+    "400"
+
+test_endpoint \
+    "Non-numeric limit (should fail)" \
+    "GET" \
+    "/strings?limit=abc" \
+    "" \
+    "400"
+
+test_endpoint \
+    "Sort by length descending" \
+    "GET" \
+    "/strings?sort_by=length&order=desc" \
+    "" \
+    "200"
+
+test_endpoint \
+    "Unknown sort_by (should fail)" \
+    "GET" \
+    "/strings?sort_by=color" \
+    "" \
+    "400"
+
+test_endpoint \
+    "Stream strings as NDJSON" \
+    "GET" \
+    "/strings?format=ndjson&is_palindrome=true" \
+    "" \
+    "200"
+
+test_endpoint \
+    "Create 'lemonade' (contains a and e)" \
+    "POST" \
+    "/strings" \
+    '{"value": "lemonade"}' \
+    "201"
+
+test_endpoint \
+    "Create 'banana' (contains a only)" \
+    "POST" \
+    "/strings" \
+    '{"value": "banana"}' \
+    "201"
+
+test_endpoint \
+    "Create 'story' (contains neither a nor e)" \
+    "POST" \
+    "/strings" \
+    '{"value": "story"}' \
+    "201"
+
+# The pattern narrows the store to the three values above
+test_values \
+    "Filter requiring both a and e (matches lemonade, not banana or story)" \
+    "/strings?contains_character=a&contains_character=e&pattern=%5E(lemonade%7Cbanana%7Cstory)%24" \
+    "lemonade"
+
+test_endpoint \
+    "Filter excluding spaces (excludes_character=%20)" \
+    "GET" \
+    "/strings?excludes_character=%20" \
+    "" \
+    "200"
+
+test_values \
+    "Filter excluding a and e (matches story only of the three)" \
+    "/strings?excludes_character=a&excludes_character=e&pattern=%5E(lemonade%7Cbanana%7Cstory)%24" \
+    "story"
+
+test_endpoint \
+    "Count palindromes without data (count_only)" \
+    "GET" \
+    "/strings?is_palindrome=true&count_only=true" \
+    "" \
+    "200"
+
+test_count=$((test_count + 1))
+echo -e "${BLUE}Test $test_count: Filter created after the epoch (all strings)${NC}"
+all_count=$(curl -s "$BASE_URL/strings?count_only=true" | grep -o '"count":[0-9]*')
+after_count=$(curl -s "$BASE_URL/strings?created_after=1970-01-01T00:00:00Z&count_only=true" | grep -o '"count":[0-9]*')
+if [ -n "$all_count" ] && [ "$all_count" != '"count":0' ] && [ "$after_count" == "$all_count" ]; then
+    echo -e "  ${GREEN}✓ PASS${NC} ($after_count)"
+    pass_count=$((pass_count + 1))
+else
+    echo -e "  ${RED}✗ FAIL${NC} (unfiltered: $all_count, created_after: $after_count)"
+    fail_count=$((fail_count + 1))
+fi
+echo ""
+
+test_values \
+    "Filter created before the epoch (no strings)" \
+    "/strings?created_before=1970-01-01T00:00:00Z"
+
+test_values \
+    "Filter with equal created_after and created_before boundaries (no strings)" \
+    "/strings?created_after=2030-01-01T00:00:00Z&created_before=2030-01-01T00:00:00Z"
+
+test_count=$((test_count + 1))
+echo -e "${BLUE}Test $test_count: Filter with offset timestamp (+ sent as %2B) matches all strings${NC}"
+offset_count=$(curl -s "$BASE_URL/strings?created_after=2000-01-01T00:00:00%2B02:00&count_only=true" | grep -o '"count":[0-9]*')
+if [ -n "$all_count" ] && [ "$offset_count" == "$all_count" ]; then
+    echo -e "  ${GREEN}✓ PASS${NC} ($offset_count)"
+    pass_count=$((pass_count + 1))
+else
+    echo -e "  ${RED}✗ FAIL${NC} (unfiltered: $all_count, created_after: $offset_count)"
+    fail_count=$((fail_count + 1))
+fi
+echo ""
+
+test_values \
+    "Filter with max_length=0 (only empty strings)" \
+    "/strings?max_length=0" \
+    ""
+
+test_values \
+    "Filter with min_length=0 keeps the empty string" \
+    "/strings?min_length=0&max_length=0" \
+    ""
+
+test_endpoint \
+    "Filter with negative min_length (should fail)" \
+    "GET" \
+    "/strings?min_length=-1" \
+    "" \
+    "400"
+
+for value in "quail" "quail two" "quail three words" "quail has four words" "quail has five words here"; do
+    curl -s -o /dev/null -X POST "$BASE_URL/strings" \
+        -H "Content-Type: application/json" -d "{\"value\": \"$value\"}"
+done
+
+# contains_substring=quail narrows the store to the values seeded above
+test_values \
+    "Filter by word count range 2-3" \
+    "/strings?min_word_count=2&max_word_count=3&contains_substring=quail" \
+    "quail two" "quail three words"
+
+test_values \
+    "Filter by word count range combined with exact word_count (AND)" \
+    "/strings?min_word_count=2&max_word_count=5&word_count=4&contains_substring=quail" \
+    "quail has four words"
+
+test_values \
+    "Filter with contradictory word count range (no strings)" \
+    "/strings?min_word_count=5&max_word_count=2"
+
+test_endpoint \
+    "Filter with invalid min_word_count (should fail)" \
+    "GET" \
+    "/strings?min_word_count=many" \
+    "" \
+    "400"
+
+test_endpoint \
+    "Filter with invalid created_after (should fail)" \
+    "GET" \
+    "/strings?created_after=yesterday" \
+    "" \
+    "400"
+
+echo "========================================="
+echo "5. NATURAL LANGUAGE FILTERING"
+echo "========================================="
+
+test_response_contains \
+    "NL Query: single word palindromes" \
+    "GET" \
+    "/strings/filter-by-natural-language?query=single%20word%20palindromes" \
+    "" \
+    "200" \
+    '"parsed_filters":{"is_palindrome":true,"word_count":1}'
+
+test_endpoint \
+    "NL Query: strings longer than 10 characters" \
+    "GET" \
+    "/strings/filter-by-natural-language?query=strings%20longer%20than%2010%20characters" \
+    "" \
+    "200"
+
+test_endpoint \
+    "NL Query: containing letter o" \
+    "GET" \
+    "/strings/filter-by-natural-language?query=containing%20letter%20o" \
+    "" \
+    "200"
+
+test_endpoint \
+    "NL Query: palindromic strings with first vowel" \
+    "GET" \
+    "/strings/filter-by-natural-language?query=palindromic%20strings%20with%20first%20vowel" \
+    "" \
+    "200"
+
+test_response_contains \
+    "NL Query: palindromes between 3 and 10 characters long" \
+    "GET" \
+    "/strings/filter-by-natural-language?query=palindromes%20between%203%20and%2010%20characters%20long" \
+    "" \
+    "200" \
+    '"parsed_filters":{"is_palindrome":true,"max_length":10,"min_length":3}'
+
+test_response_contains \
+    "NL Query: exactly 7 characters" \
+    "GET" \
+    "/strings/filter-by-natural-language?query=strings%20with%20exactly%207%20characters" \
+    "" \
+    "200" \
+    '"parsed_filters":{"max_length":7,"min_length":7}'
+
+test_response_contains \
+    "NL Query: palindromes" \
+    "GET" \
+    "/strings/filter-by-natural-language?query=palindromes" \
+    "" \
+    "200" \
+    '"parsed_filters":{"is_palindrome":true}'
+
+test_response_contains \
+    "NL Query: strings that are not palindromes" \
+    "GET" \
+    "/strings/filter-by-natural-language?query=strings%20that%20are%20not%20palindromes" \
+    "" \
+    "200" \
+    '"parsed_filters":{"is_palindrome":false}'
+
+test_endpoint \
+    "NL Query: containing the word world" \
+    "GET" \
+    "/strings/filter-by-natural-language?query=strings%20containing%20the%20word%20world" \
+    "" \
+    "200"
+
+test_response_contains \
+    "NL Query: at least five characters (number word)" \
+    "GET" \
+    "/strings/filter-by-natural-language?query=strings%20at%20least%20five%20characters%20long" \
+    "" \
+    "200" \
+    '"parsed_filters":{"min_length":5}'
+
+test_response_contains \
+    "NL Query: at least 5 characters (digits)" \
+    "GET" \
+    "/strings/filter-by-natural-language?query=strings%20at%20least%205%20characters%20long" \
+    "" \
+    "200" \
+    '"parsed_filters":{"min_length":5}'
+
+test_response_contains \
+    "NL Query: shorter than ten (number word)" \
+    "GET" \
+    "/strings/filter-by-natural-language?query=strings%20shorter%20than%20ten" \
+    "" \
+    "200" \
+    '"parsed_filters":{"max_length":9}'
+
+test_response_contains \
+    "NL Query: between three and twenty-one characters (compound number word)" \
+    "GET" \
+    "/strings/filter-by-natural-language?query=between%20three%20and%20twenty-one%20characters" \
+    "" \
+    "200" \
+    '"parsed_filters":{"max_length":21,"min_length":3}'
+
+test_response_contains \
+    "NL Query: more than 3 words" \
+    "GET" \
+    "/strings/filter-by-natural-language?query=sentences%20with%20more%20than%203%20words" \
+    "" \
+    "200" \
+    '"parsed_filters":{"min_word_count":4}'
+
+test_response_contains \
+    "NL Query: fewer than three words" \
+    "GET" \
+    "/strings/filter-by-natural-language?query=strings%20with%20fewer%20than%20three%20words" \
+    "" \
+    "200" \
+    '"parsed_filters":{"max_word_count":2}'
+
+test_response_contains \
+    "NL Query: words starting with a and ending with e" \
+    "GET" \
+    "/strings/filter-by-natural-language?query=words%20starting%20with%20a%20and%20ending%20with%20e" \
+    "" \
+    "200" \
+    '"parsed_filters":{"ends_with":"e","starts_with":"a"}'
+
+test_response_contains \
+    "NL Query: strings starting with quoted \"re\"" \
+    "GET" \
+    "/strings/filter-by-natural-language?query=strings%20starting%20with%20%22re%22" \
+    "" \
+    "200" \
+    '"parsed_filters":{"starts_with":"re"}'
+
+test_endpoint \
+    "NL Query: missing query parameter (should fail)" \
+    "GET" \
+    "/strings/filter-by-natural-language" \
+    "" \
+    "400"
+
+test_count=$((test_count + 1))
+echo -e "${BLUE}Test $test_count: NL Query reports the part it did not understand${NC}"
+body=$(curl -s "$BASE_URL/strings/filter-by-natural-language?query=palindromes%20sorted%20by%20popularity")
+if echo "$body" | grep -q '"is_palindrome":true' && echo "$body" | grep -q '"unrecognized":\["sorted by popularity"\]'; then
+    echo -e "  ${GREEN}✓ PASS${NC}"
+    pass_count=$((pass_count + 1))
+else
+    echo -e "  ${RED}✗ FAIL${NC} (Response: $body)"
+    fail_count=$((fail_count + 1))
+fi
+echo ""
+
+test_count=$((test_count + 1))
+echo -e "${BLUE}Test $test_count: Fully understood NL Query has no unrecognized words${NC}"
+body=$(curl -s "$BASE_URL/strings/filter-by-natural-language?query=strings%20longer%20than%20five%20characters%20containing%20the%20letter%20a")
+if echo "$body" | grep -q '"unrecognized":\[\]'; then
+    echo -e "  ${GREEN}✓ PASS${NC}"
+    pass_count=$((pass_count + 1))
+else
+    echo -e "  ${RED}✗ FAIL${NC} (Response: $body)"
+    fail_count=$((fail_count + 1))
+fi
+echo ""
 
 test_endpoint \
-    "Create string 'testing'" \
+    "NL Query via POST body: single word palindromes" \
     "POST" \
-    "/strings" \
-    '{"value": "testing"}' \
-    "201"
+    "/strings/filter-by-natural-language" \
+    '{"query": "single word palindromes"}' \
+    "200"
 
-test_endpoint \
-    "Create duplicate string 'racecar' (should fail)" \
-    "POST" \
-    "/strings" \
-    '{"value": "racecar"}' \
-    "409"
+test_count=$((test_count + 1))
+echo -e "${BLUE}Test $test_count: NL POST and GET return the same matches${NC}"
+get_count=$(curl -s "$BASE_URL/strings/filter-by-natural-language?query=single%20word%20palindromes" | grep -o '"count":[0-9]*')
+post_count=$(curl -s -X POST "$BASE_URL/strings/filter-by-natural-language" \
+    -H "Content-Type: application/json" -d '{"query": "single word palindromes"}' | grep -o '"count":[0-9]*')
+if [ -n "$get_count" ] && [ "$get_count" == "$post_count" ]; then
+    echo -e "  ${GREEN}✓ PASS${NC} ($get_count)"
+    pass_count=$((pass_count + 1))
+else
+    echo -e "  ${RED}✗ FAIL${NC} (GET: $get_count, POST: $post_count)"
+    fail_count=$((fail_count + 1))
+fi
+echo ""
 
 test_endpoint \
-    "Create with missing value field (should fail)" \
+    "NL Query via POST without a query (should fail)" \
     "POST" \
-    "/strings" \
+    "/strings/filter-by-natural-language" \
     '{}' \
     "400"
 
 test_endpoint \
-    "Create with empty value (should fail)" \
+    "NL Query via POST with malformed JSON (should fail)" \
     "POST" \
-    "/strings" \
-    '{"value": ""}' \
+    "/strings/filter-by-natural-language" \
+    '{"query": ' \
     "400"
 
 echo "========================================="
-echo "3. GET SPECIFIC STRING"
+echo "6. DELETE STRINGS"
 echo "========================================="
 
 test_endpoint \
-    "Get string 'racecar'" \
-    "GET" \
-    "/strings/racecar" \
+    "Delete string 'testing'" \
+    "DELETE" \
+    "/strings/testing" \
     "" \
-    "200"
+    "204"
 
 test_endpoint \
-    "Get string 'hello world' (URL encoded)" \
-    "GET" \
-    "/strings/hello%20world" \
+    "Delete same string again (should fail)" \
+    "DELETE" \
+    "/strings/testing" \
     "" \
-    "200"
+    "404"
 
 test_endpoint \
-    "Get non-existent string (should fail)" \
+    "Delete non-existent string (should fail)" \
+    "DELETE" \
+    "/strings/doesnotexist" \
+    "" \
+    "404"
+
+test_endpoint \
+    "Get soft-deleted 'testing' (should fail)" \
     "GET" \
-    "/strings/nonexistent" \
+    "/strings/testing" \
     "" \
     "404"
 
+test_json_fields \
+    "Get soft-deleted 'testing' with include_deleted" \
+    "/strings/testing?include_deleted=true" \
+    "value" "deleted_at"
+
+test_count=$((test_count + 1))
+echo -e "${BLUE}Test $test_count: Listing hides 'testing' unless include_deleted=true${NC}"
+hidden=$(curl -s "$BASE_URL/strings?contains_substring=testing" | grep -c '"value":"testing"')
+shown=$(curl -s "$BASE_URL/strings?contains_substring=testing&include_deleted=true" | grep -c '"value":"testing"')
+if [ "$hidden" == "0" ] && [ "$shown" == "1" ]; then
+    echo -e "  ${GREEN}✓ PASS${NC}"
+    pass_count=$((pass_count + 1))
+else
+    echo -e "  ${RED}✗ FAIL${NC} (without: $hidden, with include_deleted: $shown)"
+    fail_count=$((fail_count + 1))
+fi
+echo ""
+
+test_count=$((test_count + 1))
+echo -e "${BLUE}Test $test_count: Purge hard-deletes tombstones${NC}"
+purged=$(curl -s -X POST "$BASE_URL/strings/purge")
+after=$(curl -s -o /dev/null -w "%{http_code}" "$BASE_URL/strings/testing?include_deleted=true")
+if echo "$purged" | grep -q '"purged":[1-9]' && [ "$after" == "404" ]; then
+    echo -e "  ${GREEN}✓ PASS${NC} ($purged)"
+    pass_count=$((pass_count + 1))
+else
+    echo -e "  ${RED}✗ FAIL${NC} (Purge: $purged, then GET: $after)"
+    fail_count=$((fail_count + 1))
+fi
+echo ""
+
 echo "========================================="
-echo "4. GET ALL STRINGS WITH FILTERS"
+echo "7. VERIFY DELETION"
 echo "========================================="
 
 test_endpoint \
-    "Get all strings (no filters)" \
+    "Get deleted string 'testing' (should fail)" \
     "GET" \
-    "/strings" \
+    "/strings/testing" \
     "" \
-    "200"
+    "404"
 
 test_endpoint \
-    "Get palindromes only" \
+    "Get all strings (should not include 'testing')" \
     "GET" \
-    "/strings?is_palindrome=true" \
+    "/strings" \
     "" \
     "200"
 
+echo "========================================="
+echo "8. UPDATE STRINGS"
+echo "========================================="
+
 test_endpoint \
-    "Get non-palindromes" \
-    "GET" \
-    "/strings?is_palindrome=false" \
-    "" \
+    "Replace 'noon' with 'level'" \
+    "PUT" \
+    "/strings/noon" \
+    '{"value": "level"}' \
     "200"
 
 test_endpoint \
-    "Get single word strings" \
-    "GET" \
-    "/strings?word_count=1" \
+    "Re-analyze 'level' without a body" \
+    "PUT" \
+    "/strings/level" \
     "" \
     "200"
 
+test_count=$((test_count + 1))
+echo -e "${BLUE}Test $test_count: Update refreshes updated_at but keeps created_at${NC}"
+curl -s -o /dev/null -X POST "$BASE_URL/strings" -H "Content-Type: application/json" -d '{"value": "timestamped"}'
+before=$(curl -s "$BASE_URL/strings/timestamped")
+# Timestamps have one-second resolution
+sleep 1.1
+after=$(curl -s -X PUT "$BASE_URL/strings/timestamped")
+created_before=$(echo "$before" | grep -o '"created_at":"[^"]*"')
+updated_before=$(echo "$before" | grep -o '"updated_at":"[^"]*"')
+created_after=$(echo "$after" | grep -o '"created_at":"[^"]*"')
+updated_after=$(echo "$after" | grep -o '"updated_at":"[^"]*"')
+if [ -n "$created_before" ] && [ "$created_before" == "$created_after" ] \
+    && [ "${updated_before#*:}" == "${created_before#*:}" ] && [ "$updated_before" != "$updated_after" ]; then
+    echo -e "  ${GREEN}✓ PASS${NC} ($created_after, $updated_after)"
+    pass_count=$((pass_count + 1))
+else
+    echo -e "  ${RED}✗ FAIL${NC} (before: $created_before $updated_before, after: $created_after $updated_after)"
+    fail_count=$((fail_count + 1))
+fi
+echo ""
+
 test_endpoint \
-    "Get strings with min_length=5" \
-    "GET" \
-    "/strings?min_length=5" \
+    "Update non-existent string (should fail)" \
+    "PUT" \
+    "/strings/doesnotexist" \
+    '{"value": "anything"}' \
+    "404"
+
+echo "========================================="
+echo "9. CLEAR STORE"
+echo "========================================="
+
+test_endpoint \
+    "Delete all without confirm (should fail)" \
+    "DELETE" \
+    "/strings" \
     "" \
-    "200"
+    "400"
 
 test_endpoint \
-    "Get strings with max_length=10" \
-    "GET" \
-    "/strings?max_length=10" \
+    "Delete non-palindromes up to 5 characters with confirm" \
+    "DELETE" \
+    "/strings?is_palindrome=false&max_length=5&confirm=true" \
     "" \
     "200"
 
 test_endpoint \
-    "Get strings with min and max length" \
+    "Get deleted non-palindrome 'story' (should fail)" \
     "GET" \
-    "/strings?min_length=4&max_length=10" \
+    "/strings/story" \
     "" \
-    "200"
+    "404"
 
 test_endpoint \
-    "Get strings containing 'o'" \
+    "Get kept palindrome 'kayak'" \
     "GET" \
-    "/strings?contains_character=o" \
+    "/strings/kayak" \
     "" \
     "200"
 
 test_endpoint \
-    "Combined filters: palindrome + single word" \
+    "Get kept longer string 'lemonade'" \
     "GET" \
-    "/strings?is_palindrome=true&word_count=1" \
+    "/strings/lemonade" \
     "" \
     "200"
 
-echo "========================================="
-echo "5. NATURAL LANGUAGE FILTERING"
-echo "========================================="
-
 test_endpoint \
-    "NL Query: single word palindromes" \
-    "GET" \
-    "/strings/filter-by-natural-language?query=single%20word%20palindromes" \
+    "Delete by filter with invalid filter (should fail)" \
+    "DELETE" \
+    "/strings?max_length=abc&confirm=true" \
     "" \
-    "200"
+    "400"
 
 test_endpoint \
-    "NL Query: strings longer than 10 characters" \
-    "GET" \
-    "/strings/filter-by-natural-language?query=strings%20longer%20than%2010%20characters" \
+    "Delete with an invalid boolean filter (should fail)" \
+    "DELETE" \
+    "/strings?is_palindrome=yes&confirm=true" \
     "" \
-    "200"
+    "400"
 
 test_endpoint \
-    "NL Query: containing letter o" \
-    "GET" \
-    "/strings/filter-by-natural-language?query=containing%20letter%20o" \
+    "Delete with a negative word_count (should fail)" \
+    "DELETE" \
+    "/strings?word_count=-1&confirm=true" \
     "" \
-    "200"
+    "400"
 
 test_endpoint \
-    "NL Query: palindromic strings with first vowel" \
-    "GET" \
-    "/strings/filter-by-natural-language?query=palindromic%20strings%20with%20first%20vowel" \
+    "Delete with a mistyped filter name (should fail)" \
+    "DELETE" \
+    "/strings?is_palindrom=true&confirm=true" \
     "" \
-    "200"
+    "400"
 
 test_endpoint \
-    "NL Query: missing query parameter (should fail)" \
-    "GET" \
-    "/strings/filter-by-natural-language" \
+    "Delete with confirm but no filters or all=true (should fail)" \
+    "DELETE" \
+    "/strings?confirm=true" \
     "" \
     "400"
 
-echo "========================================="
-echo "6. DELETE STRINGS"
-echo "========================================="
+test_endpoint \
+    "Get 'racecar' survives the rejected deletes" \
+    "GET" \
+    "/strings/racecar" \
+    "" \
+    "200"
 
 test_endpoint \
-    "Delete string 'testing'" \
+    "Delete with all=true and a filter (should fail)" \
     "DELETE" \
-    "/strings/testing" \
+    "/strings?all=true&max_length=5&confirm=true" \
     "" \
-    "204"
+    "400"
 
 test_endpoint \
-    "Delete same string again (should fail)" \
+    "Delete all with all=true and confirm" \
     "DELETE" \
-    "/strings/testing" \
+    "/strings?all=true&confirm=true" \
     "" \
-    "404"
+    "204"
 
 test_endpoint \
-    "Delete non-existent string (should fail)" \
-    "DELETE" \
-    "/strings/doesnotexist" \
+    "Get cleared string 'racecar' (should fail)" \
+    "GET" \
+    "/strings/racecar" \
     "" \
     "404"
 
 echo "========================================="
-echo "7. VERIFY DELETION"
+echo "10. RATE LIMITING"
 echo "========================================="
 
-test_endpoint \
-    "Get deleted string 'testing' (should fail)" \
-    "GET" \
-    "/strings/testing" \
-    "" \
-    "404"
+# Runs last: it drains this client's write allowance. Reads stay unlimited.
+test_count=$((test_count + 1))
+echo -e "${BLUE}Test $test_count: Rapid writes are eventually rejected with 429${NC}"
+limited=""
+for i in $(seq 1 500); do
+    status=$(curl -s -o /dev/null -w "%{http_code}" -X POST "$BASE_URL/strings" \
+        -H "Content-Type: application/json" -d '{}')
+    if [ "$status" == "429" ]; then
+        limited=$i
+        break
+    fi
+done
+if [ -n "$limited" ]; then
+    echo -e "  ${GREEN}✓ PASS${NC} (429 after $limited requests)"
+    pass_count=$((pass_count + 1))
+else
+    echo -e "  ${RED}✗ FAIL${NC} (no 429 after 500 requests)"
+    fail_count=$((fail_count + 1))
+fi
+echo ""
 
 test_endpoint \
-    "Get all strings (should not include 'testing')" \
+    "Reads are not rate limited" \
     "GET" \
     "/strings" \
     "" \
     "200"
 
+echo "========================================="
+echo "11. HASH COLLISION DETECTION"
+echo "========================================="
+
+# Older versions derived the id from a polynomial hash (h = h*31 + c), under
+# which "Aa" and "BB" both hash to 840. Start a second server on a store file
+# written in that format and check /strings/duplicates reports the pair.
+test_count=$((test_count + 1))
+echo -e "${BLUE}Test $test_count: Duplicates endpoint detects legacy hash collisions${NC}"
+collision_file=$(mktemp)
+cat > "$collision_file" <<'EOF'
+[
+  {"id": "840", "value": "Aa", "properties": {"length": 2, "sha256_hash": "840"}, "created_at": "2024-01-01T00:00:00Z"},
+  {"id": "840", "value": "BB", "properties": {"length": 2, "sha256_hash": "840"}, "created_at": "2024-01-01T00:00:00Z"}
+]
+EOF
+if start_aux_server STORE_FILE="$collision_file"; then
+    body=$(curl -s "$AUX_URL/strings/duplicates")
+    stop_aux_server
+
+    if echo "$body" | grep -q '"count":1' && echo "$body" | grep -q '"values":\["Aa","BB"\]'; then
+        echo -e "  ${GREEN}✓ PASS${NC} (Aa and BB share id 840)"
+        pass_count=$((pass_count + 1))
+    else
+        echo -e "  ${RED}✗ FAIL${NC} (Got: $body)"
+        fail_count=$((fail_count + 1))
+    fi
+else
+    echo -e "  ${YELLOW}- SKIP${NC} (could not start the auxiliary server)"
+    skip_count=$((skip_count + 1))
+fi
+rm -f "$collision_file"
+echo ""
+
+echo "========================================="
+echo "12. CAPACITY AND LRU EVICTION"
+echo "========================================="
+
+# With MAX_ENTRIES=3, creating a fourth string evicts whichever stored string
+# was read or created longest ago. Reading "alpha" makes "bravo" the oldest.
+test_count=$((test_count + 1))
+echo -e "${BLUE}Test $test_count: Least recently used strings are evicted at capacity${NC}"
+if start_aux_server MAX_ENTRIES=3; then
+    for value in alpha bravo charlie; do
+        curl -s -o /dev/null -X POST "$AUX_URL/strings" -H "Content-Type: application/json" -d "{\"value\": \"$value\"}"
+    done
+    curl -s -o /dev/null "$AUX_URL/strings/alpha"
+    curl -s -o /dev/null -X POST "$AUX_URL/strings" -H "Content-Type: application/json" -d '{"value": "delta"}'
+    after_first=$(for value in alpha bravo charlie delta; do
+        echo -n "$value=$(curl -s -o /dev/null -w "%{http_code}" "$AUX_URL/strings/$value") "
+    done)
+    # Checking them read alpha, charlie and delta in turn, so alpha is oldest
+    curl -s -o /dev/null -X POST "$AUX_URL/strings" -H "Content-Type: application/json" -d '{"value": "echo"}'
+    after_second=$(for value in charlie delta echo alpha; do
+        echo -n "$value=$(curl -s -o /dev/null -w "%{http_code}" "$AUX_URL/strings/$value") "
+    done)
+    stop_aux_server
+
+    expected_first="alpha=200 bravo=404 charlie=200 delta=200 "
+    expected_second="charlie=200 delta=200 echo=200 alpha=404 "
+    if [ "$after_first" == "$expected_first" ] && [ "$after_second" == "$expected_second" ]; then
+        echo -e "  ${GREEN}✓ PASS${NC} (evicted bravo, then alpha)"
+        pass_count=$((pass_count + 1))
+    else
+        echo -e "  ${RED}✗ FAIL${NC} (Expected: $expected_first/ $expected_second, Got: $after_first/ $after_second)"
+        fail_count=$((fail_count + 1))
+    fi
+else
+    echo -e "  ${YELLOW}- SKIP${NC} (could not start the auxiliary server)"
+    skip_count=$((skip_count + 1))
+fi
+echo ""
+
+# Tombstones count toward MAX_ENTRIES and are evicted before live strings:
+# with "alpha" soft-deleted, creating "charlie" drops alpha's tombstone and
+# keeps "bravo".
+test_count=$((test_count + 1))
+echo -e "${BLUE}Test $test_count: Tombstones count toward capacity and are evicted first${NC}"
+if start_aux_server MAX_ENTRIES=2; then
+    for value in alpha bravo; do
+        curl -s -o /dev/null -X POST "$AUX_URL/strings" -H "Content-Type: application/json" -d "{\"value\": \"$value\"}"
+    done
+    curl -s -o /dev/null -X DELETE "$AUX_URL/strings/alpha"
+    curl -s -o /dev/null -X POST "$AUX_URL/strings" -H "Content-Type: application/json" -d '{"value": "charlie"}'
+    result=$(for value in alpha bravo charlie; do
+        echo -n "$value=$(curl -s -o /dev/null -w "%{http_code}" "$AUX_URL/strings/$value?include_deleted=true") "
+    done)
+    stop_aux_server
+
+    expected="alpha=404 bravo=200 charlie=200 "
+    if [ "$result" == "$expected" ]; then
+        echo -e "  ${GREEN}✓ PASS${NC} (evicted alpha's tombstone)"
+        pass_count=$((pass_count + 1))
+    else
+        echo -e "  ${RED}✗ FAIL${NC} (Expected: $expected, Got: $result)"
+        fail_count=$((fail_count + 1))
+    fi
+else
+    echo -e "  ${YELLOW}- SKIP${NC} (could not start the auxiliary server)"
+    skip_count=$((skip_count + 1))
+fi
+echo ""
+
+echo "========================================="
+echo "13. HASH ALGORITHMS"
+echo "========================================="
+
+# Each HASH_ALGORITHM must give the well-known digest of "hello" as both the
+# id and the hash property, while sha256_hash stays SHA-256:
+# algorithm|digest
+while IFS='|' read -r algorithm digest; do
+    test_count=$((test_count + 1))
+    echo -e "${BLUE}Test $test_count: HASH_ALGORITHM=$algorithm digest of 'hello'${NC}"
+    if start_aux_server HASH_ALGORITHM="$algorithm"; then
+        body=$(curl -s "$AUX_URL/strings/analyze?value=hello")
+        stop_aux_server
+
+        if echo "$body" | grep -qF "\"id\":\"$digest\"" \
+            && echo "$body" | grep -qF "\"hash\":\"$digest\",\"hash_algorithm\":\"$algorithm\"" \
+            && echo "$body" | grep -qF '"sha256_hash":"2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824"'; then
+            echo -e "  ${GREEN}✓ PASS${NC} ($digest)"
+            pass_count=$((pass_count + 1))
+        else
+            echo -e "  ${RED}✗ FAIL${NC} (Expected id and hash $digest, Got: $body)"
+            fail_count=$((fail_count + 1))
+        fi
+    else
+        echo -e "  ${YELLOW}- SKIP${NC} (could not start the auxiliary server)"
+        skip_count=$((skip_count + 1))
+    fi
+    echo ""
+done <<'EOF'
+sha256|2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824
+sha1|aaf4c61ddcc5e8a2dabede0f3b482cd9aea9434d
+sha512|9b71d224bd62f3785d96d46ad3ea3d73319bfbc2890caadae2dff72519673ca72323c3d99ba5c11d7c7acc6e14b8c5da0c4663475c2e5c3adef46f73bcdec043
+md5|5d41402abc4b2a76b9719d911017c592
+EOF
+
+echo "========================================="
+echo "14. PERSISTENCE ACROSS RESTARTS"
+echo "========================================="
+
+# Everything saved to STORE_FILE, including the empty string allow_empty=true
+# permits, must be loaded again when a new server starts on the file.
+test_count=$((test_count + 1))
+echo -e "${BLUE}Test $test_count: File store reloads the empty string after a restart${NC}"
+persist_dir=$(mktemp -d)
+if start_aux_server STORE_FILE="$persist_dir/store.json"; then
+    curl -s -o /dev/null -X POST "$AUX_URL/strings?allow_empty=true" -H "Content-Type: application/json" -d '{"value": ""}'
+    curl -s -o /dev/null -X POST "$AUX_URL/strings" -H "Content-Type: application/json" -d '{"value": "hello"}'
+    before=$(curl -s "$AUX_URL/strings?count_only=true")
+    stop_aux_server
+
+    start_aux_server STORE_FILE="$persist_dir/store.json"
+    after=$(curl -s "$AUX_URL/strings?count_only=true")
+    empty=$(curl -s "$AUX_URL/strings?max_length=0&count_only=true")
+    stop_aux_server
+
+    if echo "$before" | grep -q '"count":2' && [ "$after" == "$before" ] && echo "$empty" | grep -q '"count":1'; then
+        echo -e "  ${GREEN}✓ PASS${NC} (2 strings before and after, empty string kept)"
+        pass_count=$((pass_count + 1))
+    else
+        echo -e "  ${RED}✗ FAIL${NC} (before: $before, after: $after, empty: $empty)"
+        fail_count=$((fail_count + 1))
+    fi
+else
+    echo -e "  ${YELLOW}- SKIP${NC} (could not start the auxiliary server)"
+    skip_count=$((skip_count + 1))
+fi
+rm -rf "$persist_dir"
+echo ""
+
+echo "========================================="
+echo "15. CORS"
+echo "========================================="
+
+# With CORS_ALLOWED_ORIGINS set, only listed origins are echoed back in
+# Access-Control-Allow-Origin; others get no header at all. Without it, the
+# server on BASE_URL answers every origin with "*":
+# server|origin|expected header
+while IFS='|' read -r server origin expected; do
+    test_count=$((test_count + 1))
+    echo -e "${BLUE}Test $test_count: Origin $origin on the $server server${NC}"
+    if [ "$server" == "default" ] || start_aux_server CORS_ALLOWED_ORIGINS="https://allowed.example,https://other.example"; then
+        url=$BASE_URL
+        [ "$server" == "restricted" ] && url=$AUX_URL
+        value=$(curl -s -D - -o /dev/null -H "Origin: $origin" "$url/health" \
+            | grep -i "^Access-Control-Allow-Origin:" | cut -d' ' -f2- | tr -d '\r')
+        [ "$server" == "restricted" ] && stop_aux_server
+
+        if [ "$value" == "$expected" ]; then
+            echo -e "  ${GREEN}✓ PASS${NC} (Access-Control-Allow-Origin: ${value:-none})"
+            pass_count=$((pass_count + 1))
+        else
+            echo -e "  ${RED}✗ FAIL${NC} (Expected: ${expected:-none}, Got: ${value:-none})"
+            fail_count=$((fail_count + 1))
+        fi
+    else
+        echo -e "  ${YELLOW}- SKIP${NC} (could not start the auxiliary server)"
+        skip_count=$((skip_count + 1))
+    fi
+    echo ""
+done <<'EOF'
+default|https://anything.example|*
+restricted|https://allowed.example|https://allowed.example
+restricted|https://evil.example|
+EOF
+
+echo "========================================="
+echo "16. MAXIMUM STRING LENGTH"
+echo "========================================="
+
+# With MAX_STRING_LENGTH=5, values of exactly 5 runes (multi-byte ones
+# included) are accepted and 6 runes are rejected with 413. A body far larger
+# than any 5-rune value needs is cut off by MaxBytesReader, also with 413:
+# description|body|expected status|expected body fragment
+max_length_padding=$(printf '%*s' 2000 '')
+start_aux_server MAX_STRING_LENGTH=5
+aux_started=$?
+while IFS='|' read -r description data expected_status expected_body; do
+    test_count=$((test_count + 1))
+    echo -e "${BLUE}Test $test_count: $description${NC}"
+    if [ $aux_started -eq 0 ]; then
+        response=$(curl -s -w "\n%{http_code}" -X POST "$AUX_URL/strings" \
+            -H "Content-Type: application/json" -d "${data/PADDING/$max_length_padding}")
+        http_code=$(echo "$response" | tail -n1)
+        body=$(echo "$response" | head -n-1)
+
+        if [ "$http_code" == "$expected_status" ] && echo "$body" | grep -qF "$expected_body"; then
+            echo -e "  ${GREEN}✓ PASS${NC} (Status: $http_code)"
+            pass_count=$((pass_count + 1))
+        else
+            echo -e "  ${RED}✗ FAIL${NC} (Expected: $expected_status with $expected_body, Got: $http_code)"
+            fail_count=$((fail_count + 1))
+        fi
+        echo "  Response: $body"
+    else
+        echo -e "  ${YELLOW}- SKIP${NC} (could not start the auxiliary server)"
+        skip_count=$((skip_count + 1))
+    fi
+    echo ""
+done <<'EOF'
+Value of exactly MAX_STRING_LENGTH runes is accepted|{"value": "abcde"}|201|"value":"abcde"
+Multi-byte value of exactly MAX_STRING_LENGTH runes is accepted|{"value": "ééééé"}|201|"length":5
+Value one rune over MAX_STRING_LENGTH is rejected|{"value": "abcdef"}|413|maximum length of 5 characters
+Oversized request body is cut off by MaxBytesReader|{"value": "a"PADDING}|413|Request body exceeds
+EOF
+
+# Read-only endpoints that compare values pairwise bound them the same way:
+# description|endpoint
+while IFS='|' read -r description endpoint; do
+    test_count=$((test_count + 1))
+    echo -e "${BLUE}Test $test_count: $description${NC}"
+    if [ $aux_started -eq 0 ]; then
+        http_code=$(curl -s -o /dev/null -w "%{http_code}" "$AUX_URL$endpoint")
+        if [ "$http_code" == "413" ]; then
+            echo -e "  ${GREEN}✓ PASS${NC} (Status: $http_code)"
+            pass_count=$((pass_count + 1))
+        else
+            echo -e "  ${RED}✗ FAIL${NC} (Expected: 413, Got: $http_code)"
+            fail_count=$((fail_count + 1))
+        fi
+    else
+        echo -e "  ${YELLOW}- SKIP${NC} (could not start the auxiliary server)"
+        skip_count=$((skip_count + 1))
+    fi
+    echo ""
+done <<'EOF'
+Diff with a value one rune over MAX_STRING_LENGTH is rejected|/strings/diff?a=abcdef&b=abc
+Similar strings for a value one rune over MAX_STRING_LENGTH is rejected|/strings/abcdef/similar
+EOF
+[ $aux_started -eq 0 ] && stop_aux_server
+
 echo "========================================="
 echo "TEST SUMMARY"
 echo "========================================="
 echo -e "Total Tests: $test_count"
 echo -e "${GREEN}Passed: $pass_count${NC}"
 echo -e "${RED}Failed: $fail_count${NC}"
+echo -e "${YELLOW}Skipped: $skip_count${NC}"
 echo ""
 
-if [ $fail_count -eq 0 ]; then
+if [ $fail_count -eq 0 ] && [ $skip_count -gt 0 ]; then
+    echo -e "${GREEN}🎉 All tests that ran passed${NC} ${YELLOW}($skip_count skipped)${NC}"
+    exit 0
+elif [ $fail_count -eq 0 ]; then
     echo -e "${GREEN}🎉 All tests passed!${NC}"
     exit 0
 else